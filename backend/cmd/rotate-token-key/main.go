@@ -0,0 +1,35 @@
+// Command rotate-token-key re-encrypts every stored OAuth token with the current encryption key.
+//
+// To rotate the key, set TOKEN_ENCRYPTION_KEY to the new key and add the old key to
+// TOKEN_ENCRYPTION_PREVIOUS_KEYS, deploy, then run:
+//
+//	go run ./cmd/rotate-token-key
+//
+// Once it has finished the old key can be removed from TOKEN_ENCRYPTION_PREVIOUS_KEYS.
+// Running it after upgrading also encrypts tokens that were stored in plaintext.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "number of tokens loaded at a time")
+	flag.Parse()
+
+	config.LoadConfig()
+	if _, err := db.ConnectDB(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	rewritten, err := repositories.ReencryptTokens(*batchSize)
+	if err != nil {
+		log.Fatalf("Token re-encryption stopped after %d tokens: %v", rewritten, err)
+	}
+	log.Printf("Re-encrypted %d tokens with the current key", rewritten)
+}
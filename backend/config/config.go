@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"sync"
 
@@ -16,6 +17,13 @@ type envConfig struct {
 	GitHubClientID     string `env:"GITHUB_CLIENT_ID,required"`
 	GitHubClientSecret string `env:"GITHUB_CLIENT_SECRET,required"`
 	GithubCallbackURL  string `env:"GITHUB_CALLBACK_URL" envDefault:"http://localhost:9753/auth/github/callback"`
+	// GitHub App configs: optional, installations can only be linked when they are set.
+	// The private key is the PEM file contents; escaped "\n" sequences are accepted.
+	GitHubAppID           string `env:"GITHUB_APP_ID"`
+	GitHubAppSlug         string `env:"GITHUB_APP_SLUG"`
+	GitHubAppClientID     string `env:"GITHUB_APP_CLIENT_ID"`
+	GitHubAppClientSecret string `env:"GITHUB_APP_CLIENT_SECRET"`
+	GitHubAppPrivateKey   string `env:"GITHUB_APP_PRIVATE_KEY"`
 	// Database configs
 	DatabaseUrl string `env:"DATABASE_URL,required"`
 	// Server configs
@@ -23,12 +31,35 @@ type envConfig struct {
 	IsProduction bool `env:"IS_PRODUCTION" envDefault:"false"`
 	// JWTSecret
 	JWTSecret string `env:"JWT_SECRET,required"`
+	// Session configs: access JWTs are short-lived and renewed with rotating refresh tokens
+	AccessTokenTTLMinutes int `env:"ACCESS_TOKEN_TTL_MINUTES" envDefault:"15"`
+	RefreshTokenTTLDays   int `env:"REFRESH_TOKEN_TTL_DAYS" envDefault:"30"`
+	// OAuth state configs: "postgres" shares pending states between instances, "memory" keeps them in-process
+	OAuthStateStore                  string `env:"OAUTH_STATE_STORE" envDefault:"postgres"`
+	OAuthStateTTLMinutes             int    `env:"OAUTH_STATE_TTL_MINUTES" envDefault:"10"`
+	OAuthStateMaxPending             int    `env:"OAUTH_STATE_MAX_PENDING" envDefault:"10000"`
+	OAuthStateMaxPendingPerClient    int    `env:"OAUTH_STATE_MAX_PENDING_PER_CLIENT" envDefault:"20"`
+	OAuthStateCleanupIntervalMinutes int    `env:"OAUTH_STATE_CLEANUP_INTERVAL_MINUTES" envDefault:"5"`
+	// Token encryption configs: base64-encoded 256-bit keys. Previous keys are only used to
+	// decrypt tokens that have not been re-encrypted yet (see cmd/rotate-token-key)
+	TokenEncryptionKey          string   `env:"TOKEN_ENCRYPTION_KEY,required"`
+	TokenEncryptionPreviousKeys []string `env:"TOKEN_ENCRYPTION_PREVIOUS_KEYS" envSeparator:","`
 	// S3 configs
 	AWSAccessKeyID     string `env:"AWS_ACCESS_KEY_ID,required"`
 	AWSSecretAccessKey string `env:"AWS_SECRET_ACCESS_KEY,required"`
 	AWSRegion          string `env:"AWS_REGION,required"`
 	S3BucketName       string `env:"S3_BUCKET_NAME,required"`
 	S3MaxUploadSizeMB  int    `env:"S3_MAX_UPLOAD_SIZE_MB" envDefault:"5"`
+	// Content hashing configs: how often completed uploads that have not been hashed yet are picked up
+	ContentHashIntervalMinutes int `env:"CONTENT_HASH_INTERVAL_MINUTES" envDefault:"10"`
+	// Trash configs
+	TrashRetentionDays        int `env:"TRASH_RETENTION_DAYS" envDefault:"30"`
+	TrashPurgeIntervalMinutes int `env:"TRASH_PURGE_INTERVAL_MINUTES" envDefault:"60"`
+	// Storage configs: how much chunk data a storage repository may hold
+	GitHubRepoCapacityMB int `env:"GITHUB_REPO_CAPACITY_MB" envDefault:"500"`
+	// Repository compaction configs
+	CompactionIntervalMinutes  int `env:"COMPACTION_INTERVAL_MINUTES" envDefault:"360"`
+	CompactionMinReclaimableMB int `env:"COMPACTION_MIN_RECLAIMABLE_MB" envDefault:"50"`
 }
 
 var (
@@ -43,8 +74,28 @@ func LoadConfig() *envConfig {
 		if err := env.Parse(tmpConfig); err != nil {
 			log.Fatalf("Failed to load config: %v", err)
 		}
+		if err := tmpConfig.validate(); err != nil {
+			log.Fatalf("Invalid config: %v", err)
+		}
 		log.Println("config loaded successfully")
 		instance = tmpConfig
 	})
 	return instance
 }
+
+// validate checks values that would otherwise only fail once they are used,
+// such as worker intervals that time.NewTicker rejects
+func (c *envConfig) validate() error {
+	intervals := map[string]int{
+		"CONTENT_HASH_INTERVAL_MINUTES":        c.ContentHashIntervalMinutes,
+		"TRASH_PURGE_INTERVAL_MINUTES":         c.TrashPurgeIntervalMinutes,
+		"OAUTH_STATE_CLEANUP_INTERVAL_MINUTES": c.OAuthStateCleanupIntervalMinutes,
+		"COMPACTION_INTERVAL_MINUTES":          c.CompactionIntervalMinutes,
+	}
+	for name, minutes := range intervals {
+		if minutes <= 0 {
+			return fmt.Errorf("%s must be greater than 0, got %d", name, minutes)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package crypto_util encrypts secrets that are stored at rest, such as OAuth tokens
+package crypto_util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+)
+
+// ciphertextPrefix marks values produced by Encrypt, so rows written before encryption was
+// introduced can still be read as plaintext until they are re-encrypted
+const ciphertextPrefix = "enc:v1:"
+
+// ErrUnknownKey is returned when a value was encrypted with a key that is no longer configured
+var ErrUnknownKey = errors.New("value was encrypted with an unknown key")
+
+// keyring holds the AEAD of the current key, used for encryption, and of every key that may
+// still be needed for decryption, indexed by key ID
+type keyring struct {
+	currentID string
+	ciphers   map[string]cipher.AEAD
+}
+
+var (
+	keys    *keyring
+	keyOnce sync.Once
+	keyErr  error
+)
+
+// loadKeys builds the keyring from TOKEN_ENCRYPTION_KEY and TOKEN_ENCRYPTION_PREVIOUS_KEYS
+func loadKeys() (*keyring, error) {
+	keyOnce.Do(func() {
+		cfg := config.LoadConfig()
+		ring := &keyring{ciphers: make(map[string]cipher.AEAD)}
+
+		ring.currentID, keyErr = ring.add(cfg.TokenEncryptionKey)
+		if keyErr != nil {
+			keyErr = fmt.Errorf("invalid TOKEN_ENCRYPTION_KEY: %w", keyErr)
+			return
+		}
+		for _, previous := range cfg.TokenEncryptionPreviousKeys {
+			if _, keyErr = ring.add(previous); keyErr != nil {
+				keyErr = fmt.Errorf("invalid TOKEN_ENCRYPTION_PREVIOUS_KEYS: %w", keyErr)
+				return
+			}
+		}
+		keys = ring
+	})
+	return keys, keyErr
+}
+
+// add registers a base64-encoded 256-bit key and returns its ID
+func (r *keyring) add(encodedKey string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return "", err
+	}
+	if len(key) != 32 {
+		return "", fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	// The ID only identifies the key; it is a truncated hash, so it does not reveal the key
+	sum := sha256.Sum256(key)
+	id := hex.EncodeToString(sum[:4])
+	r.ciphers[id] = aead
+	return id, nil
+}
+
+// Encrypt seals a value with AES-256-GCM under the current key.
+// The result has the form "enc:v1:<key id>:<base64 nonce and ciphertext>".
+func Encrypt(plaintext string) (string, error) {
+	ring, err := loadKeys()
+	if err != nil {
+		return "", err
+	}
+	aead := ring.ciphers[ring.currentID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(ring.currentID))
+	return ciphertextPrefix + ring.currentID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt with whichever configured key sealed it.
+// Values without the ciphertext prefix are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	ring, err := loadKeys()
+	if err != nil {
+		return "", err
+	}
+
+	id, encoded, found := strings.Cut(strings.TrimPrefix(value, ciphertextPrefix), ":")
+	if !found {
+		return "", errors.New("malformed ciphertext")
+	}
+	aead, ok := ring.ciphers[id]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed ciphertext")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix)
+}
+
+// IsCurrentKey reports whether an encrypted value was sealed with the current key
+func IsCurrentKey(value string) bool {
+	ring, err := loadKeys()
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(value, ciphertextPrefix+ring.currentID+":")
+}
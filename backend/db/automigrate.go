@@ -1,15 +1,77 @@
 package db
 
-import "github.com/AnshJain-Shwalia/DataHub/backend/models"
+import (
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+)
+
+// postMigrationStatements holds schema objects GORM's struct tags cannot express
+var postMigrationStatements = []string{
+	// Names are unique per (user, parent folder) among live items; trashed items keep their name
+	// so they can be restored. NULL parents (the user's root) are folded into the nil UUID because
+	// a plain unique index treats every NULL as distinct.
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_folders_unique_name
+		ON folders (user_id, COALESCE(parent_folder_id, '00000000-0000-0000-0000-000000000000'::uuid), name)
+		WHERE deleted_at IS NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_files_unique_name
+		ON files (user_id, COALESCE(folder_id, '00000000-0000-0000-0000-000000000000'::uuid), name)
+		WHERE deleted_at IS NULL`,
+	// Trigram indexes serve both substring (ILIKE) and fuzzy name search
+	`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	`CREATE INDEX IF NOT EXISTS idx_folders_name_trgm ON folders USING gin (name gin_trgm_ops) WHERE deleted_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_files_name_trgm ON files USING gin (name gin_trgm_ops) WHERE deleted_at IS NULL`,
+}
+
+// versionBackfillStatements record the content of files uploaded before versioning as version 1.
+// They only run when the file_versions table is created, so they are a one-time migration.
+var versionBackfillStatements = []string{
+	`INSERT INTO file_versions (id, file_id, number, size, status, created_at)
+		SELECT gen_random_uuid(), f.id, 1, f.size, f.status, f.created_at FROM files f
+		WHERE NOT EXISTS (SELECT 1 FROM file_versions v WHERE v.file_id = f.id)`,
+	`UPDATE chunks SET version_id = v.id FROM file_versions v
+		WHERE chunks.version_id IS NULL AND v.file_id = chunks.file_id AND v.number = 1`,
+	`UPDATE files SET current_version_id = v.id FROM file_versions v
+		WHERE files.current_version_id IS NULL AND files.status = 'COMPLETE' AND v.file_id = files.id AND v.number = 1`,
+}
 
 func AutoMigrate() error {
-	return DB.AutoMigrate(
+	backfillVersions := !DB.Migrator().HasTable(&models.FileVersion{})
+
+	err := DB.AutoMigrate(
 		&models.User{},
 		&models.Token{},
 		&models.Repo{},
 		&models.Branch{},
 		&models.Folder{},
 		&models.File{},
+		&models.FileVersion{},
 		&models.Chunk{},
+		&models.FileTag{},
+		&models.FileMetadata{},
+		&models.UserKey{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.OAuthState{},
 	)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range postMigrationStatements {
+		if err := DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	if !backfillVersions {
+		return nil
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range versionBackfillStatements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
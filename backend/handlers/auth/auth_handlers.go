@@ -10,6 +10,36 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// clientInfo describes the client making the request, for recording on its session
+func clientInfo(c *gin.Context) authservice.ClientInfo {
+	return authservice.ClientInfo{
+		Device:    c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// authErrorStatus maps auth error codes to HTTP status codes for the session, account
+// management, OAuth URL and GitHub App installation endpoints
+func authErrorStatus(code string) int {
+	switch code {
+	case "INVALID_REFRESH_TOKEN", "REFRESH_TOKEN_REUSED":
+		return http.StatusUnauthorized
+	case "INVALID_STATE", "TOKEN_EXCHANGE_FAILED":
+		return http.StatusBadRequest
+	case "ACCOUNT_NOT_FOUND", "INSTALLATION_NOT_FOUND":
+		return http.StatusNotFound
+	case "INSUFFICIENT_STORAGE", "ACCOUNT_NOT_EMPTY":
+		return http.StatusConflict
+	case "EVACUATION_FAILED", "INSTALLATION_RETRIEVAL_FAILED", "INSTALLATION_TOKEN_FAILED":
+		return http.StatusBadGateway
+	case "TOO_MANY_PENDING_STATES":
+		return http.StatusTooManyRequests
+	case "GITHUB_APP_NOT_CONFIGURED":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
 
 // GoogleAuthCodeHandler processes the OAuth2 authorization code received from Google's OAuth flow.
 // The actual business logic is handled by the GoogleAuthService.
@@ -21,7 +51,7 @@ func GoogleAuthCodeHandler(c *gin.Context) {
 	}
 
 	googleAuthService := authservice.NewGoogleAuthService()
-	response, err := googleAuthService.ProcessAuthCode(&body)
+	response, err := googleAuthService.ProcessAuthCode(&body, clientInfo(c))
 	if err != nil {
 		if authErr, ok := err.(*authservice.AuthError); ok {
 			c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, authErr.Message, authErr.Details))
@@ -37,8 +67,13 @@ func GoogleAuthCodeHandler(c *gin.Context) {
 // GenerateGoogleOAuthURLHandler generates the OAuth URL for Google login
 func GenerateGoogleOAuthURLHandler(c *gin.Context) {
 	googleAuthService := authservice.NewGoogleAuthService()
-	authURL, err := googleAuthService.GenerateOAuthURL()
+	authURL, err := googleAuthService.GenerateOAuthURL(c.ClientIP())
 	if err != nil {
+		if authErr, ok := err.(*authservice.AuthError); ok {
+			status := authErrorStatus(authErr.Code)
+			c.JSON(status, http_util.NewErrorResponse(status, authErr.Message, authErr.Details))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "", nil))
 		return
 	}
@@ -104,11 +139,161 @@ func GetGitHubAccountsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// RefreshTokenHandler exchanges a refresh token for a new access JWT and refresh token.
+// Each refresh token can be used once; reusing one revokes the whole session family.
+func RefreshTokenHandler(c *gin.Context) {
+	var body authservice.RefreshRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	authService := authservice.NewAuthService()
+	response, err := authService.RefreshSession(&body, clientInfo(c))
+	if err != nil {
+		if authErr, ok := err.(*authservice.AuthError); ok {
+			status := authErrorStatus(authErr.Code)
+			c.JSON(status, http_util.NewErrorResponse(status, authErr.Message, authErr.Details))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "Failed to refresh session", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveGitHubAccountHandler unlinks a GitHub OAuth storage account from the authenticated user.
+// The account's data is first moved into the user's other GitHub accounts; the request is refused
+// if they do not have enough room. With ?deleteRepos=true the account's storage repositories are
+// also deleted on GitHub.
+// The actual business logic is handled by the GitHubAuthService.
+func RemoveGitHubAccountHandler(c *gin.Context) {
+	// Extract user ID from JWT token (user must be already authenticated)
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	githubAuthService := authservice.NewGitHubAuthService()
+	response, err := githubAuthService.RemoveAccount(userID, c.Param("login"), c.Query("deleteRepos") == "true")
+	if err != nil {
+		if authErr, ok := err.(*authservice.AuthError); ok {
+			status := authErrorStatus(authErr.Code)
+			c.JSON(status, http_util.NewErrorResponse(status, authErr.Message, authErr.Details))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "Failed to remove GitHub account", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddGitHubInstallationHandler links a GitHub App installation to the authenticated user as a
+// storage account, using the installation ID, code and state GitHub passed to the app's setup URL.
+// The actual business logic is handled by the GitHubAuthService.
+func AddGitHubInstallationHandler(c *gin.Context) {
+	// Extract user ID from JWT token (user must be already authenticated)
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body authservice.AddInstallationRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	githubAuthService := authservice.NewGitHubAuthService()
+	response, err := githubAuthService.AddInstallation(userID, &body)
+	if err != nil {
+		if authErr, ok := err.(*authservice.AuthError); ok {
+			status := authErrorStatus(authErr.Code)
+			c.JSON(status, http_util.NewErrorResponse(status, authErr.Message, authErr.Details))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "Failed to link GitHub App installation", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveGitHubInstallationHandler unlinks a GitHub App installation from the authenticated user.
+// Like RemoveGitHubAccountHandler, the installation's data is first moved into the user's other
+// GitHub accounts, and ?deleteRepos=true also deletes its storage repositories on GitHub.
+// The actual business logic is handled by the GitHubAuthService.
+func RemoveGitHubInstallationHandler(c *gin.Context) {
+	// Extract user ID from JWT token (user must be already authenticated)
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	githubAuthService := authservice.NewGitHubAuthService()
+	response, err := githubAuthService.RemoveInstallation(userID, c.Param("account"), c.Query("deleteRepos") == "true")
+	if err != nil {
+		if authErr, ok := err.(*authservice.AuthError); ok {
+			status := authErrorStatus(authErr.Code)
+			c.JSON(status, http_util.NewErrorResponse(status, authErr.Message, authErr.Details))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "Failed to remove GitHub App installation", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GenerateGitHubAppInstallURLHandler generates the URL where the user installs the GitHub App
+func GenerateGitHubAppInstallURLHandler(c *gin.Context) {
+	// Extract user ID from JWT token; the state is bound to this user
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	githubAuthService := authservice.NewGitHubAuthService()
+	installURL, err := githubAuthService.GenerateAppInstallURL(userID)
+	if err != nil {
+		if authErr, ok := err.(*authservice.AuthError); ok {
+			status := authErrorStatus(authErr.Code)
+			c.JSON(status, http_util.NewErrorResponse(status, authErr.Message, authErr.Details))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"installURL": installURL,
+		"success":    true,
+	})
+}
+
 // GenerateGitHubOAuthURLHandler generates the OAuth URL for GitHub login
 func GenerateGitHubOAuthURLHandler(c *gin.Context) {
+	// Extract user ID from JWT token; the OAuth state is bound to this user
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
 	githubAuthService := authservice.NewGitHubAuthService()
-	authURL, err := githubAuthService.GenerateOAuthURL()
+	authURL, err := githubAuthService.GenerateOAuthURL(userID)
 	if err != nil {
+		if authErr, ok := err.(*authservice.AuthError); ok {
+			status := authErrorStatus(authErr.Code)
+			c.JSON(status, http_util.NewErrorResponse(status, authErr.Message, authErr.Details))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "", nil))
 		return
 	}
@@ -129,17 +314,19 @@ func GetSigninTokenByIDHandler(c *gin.Context) {
 	}
 
 	authService := authservice.NewAuthService()
-	tokenString, user, err := authService.GetTokenByUserID(userID)
+	tokens, user, err := authService.GetTokenByUserID(userID, clientInfo(c))
 	if err != nil {
 		log.Printf("Failed to generate token for user %s: %v", userID, err)
 		c.JSON(http.StatusNotFound, http_util.NewErrorResponse(http.StatusNotFound, "User not found", nil))
 		return
 	}
 
-	// Return the JWT token
+	// Return the session tokens
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"token":   tokenString,
+		"success":      true,
+		"token":        tokens.AccessToken,
+		"expiresAt":    tokens.AccessExpiresAt,
+		"refreshToken": tokens.RefreshToken,
 		"user": gin.H{
 			"id":    user.ID,
 			"email": user.Email,
@@ -0,0 +1,434 @@
+package file
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	fileservice "github.com/AnshJain-Shwalia/DataHub/backend/services/file"
+	"github.com/gin-gonic/gin"
+)
+
+// fileErrorStatus maps file error codes to HTTP status codes
+func fileErrorStatus(code string) int {
+	switch code {
+	case "INVALID_NAME", "INVALID_CONFLICT_POLICY", "DESTINATION_NOT_FOUND", "INVALID_PRUNE_RULES", "INVALID_ATTRIBUTES",
+		"INVALID_HASH", "INVALID_CHUNK_HASHES", "INVALID_ENCRYPTION":
+		return http.StatusBadRequest
+	case "FILE_NOT_FOUND", "VERSION_NOT_FOUND", "THUMBNAIL_NOT_FOUND":
+		return http.StatusNotFound
+	case "UPLOAD_INCOMPLETE", "NAME_CONFLICT", "ENCRYPTION_MISMATCH", "ENCRYPTION_NOT_CONFIGURED":
+		return http.StatusConflict
+	case "STORAGE_UNAVAILABLE":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RespondWithFileError writes an error returned by the FileService to the response
+func RespondWithFileError(c *gin.Context, err error, fallbackMessage string) {
+	if fileErr, ok := err.(*fileservice.FileError); ok {
+		status := fileErrorStatus(fileErr.Code)
+		c.JSON(status, http_util.NewErrorResponse(status, fileErr.Message, fileErr.Details))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, fallbackMessage, err.Error()))
+}
+
+// GetFileHandler returns the metadata of a single file owned by the authenticated user
+func GetFileHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.GetFile(userID, c.Param("id"))
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to retrieve file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// RenameFileHandler renames a file owned by the authenticated user
+func RenameFileHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body fileservice.RenameFileRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.RenameFile(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to rename file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// MoveFileHandler moves (and optionally renames) a file owned by the authenticated user
+func MoveFileHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body fileservice.MoveFileRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.MoveFile(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to move file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// CopyFileHandler copies a file owned by the authenticated user without re-uploading its content
+func CopyFileHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body fileservice.CopyFileRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.CopyFile(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to copy file")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// DeleteFileHandler moves a file owned by the authenticated user to the trash
+func DeleteFileHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	if err := fileService.DeleteFile(userID, c.Param("id")); err != nil {
+		RespondWithFileError(c, err, "Failed to delete file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "File moved to trash",
+	})
+}
+
+// InitiateUploadHandler creates a new file for the authenticated user and returns
+// pre-signed upload URLs for its chunks
+func InitiateUploadHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body fileservice.InitiateUploadRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	response, err := fileService.InitiateUpload(userID, &body)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to start upload")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// FinalizeUploadHandler marks a file as complete once all of its chunks are in the buffer
+func FinalizeUploadHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.FinalizeUpload(userID, c.Param("id"))
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to finalize upload")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// DownloadFileHandler streams the content of a file owned by the authenticated user
+func DownloadFileHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	download, err := fileService.PrepareDownload(userID, c.Param("id"))
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to download file")
+		return
+	}
+
+	WriteDownload(c, download)
+}
+
+// SetFileTagsHandler replaces the tags of a file owned by the authenticated user
+func SetFileTagsHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body fileservice.SetTagsRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.SetTags(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to update file tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// SetFileMetadataHandler replaces the key/value metadata of a file owned by the authenticated user
+func SetFileMetadataHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body fileservice.SetMetadataRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.SetMetadata(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to update file metadata")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// FindFilesHandler returns the authenticated user's files that carry the requested tags and metadata,
+// e.g. ?tag=apollo&tag=raw&meta[owner]=alice
+func FindFilesHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	request := fileservice.FindFilesRequest{
+		Tags:     c.QueryArray("tag"),
+		Metadata: c.QueryMap("meta"),
+	}
+	var err error
+	if value := c.Query("limit"); value != "" {
+		if request.Limit, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Invalid query parameters", "limit must be an integer"))
+			return
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if request.Offset, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Invalid query parameters", "offset must be an integer"))
+			return
+		}
+	}
+
+	fileService := fileservice.NewFileService()
+	response, err := fileService.FindFiles(userID, &request)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to retrieve files")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetThumbnailHandler serves the JPEG thumbnail of an image file owned by the authenticated user
+func GetThumbnailHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	thumbnail, err := fileService.OpenThumbnail(userID, c.Param("id"))
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to retrieve thumbnail")
+		return
+	}
+	defer thumbnail.Close()
+
+	// Thumbnails are immutable per version, so clients may cache them until the file changes
+	c.DataFromReader(http.StatusOK, -1, "image/jpeg", thumbnail, map[string]string{
+		"Cache-Control": "private, max-age=3600",
+	})
+}
+
+// ListFileVersionsHandler returns every version of a file owned by the authenticated user
+func ListFileVersionsHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	response, err := fileService.ListVersions(userID, c.Param("id"))
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to retrieve file versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DownloadFileVersionHandler streams the content of a specific version of a file
+func DownloadFileVersionHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	download, err := fileService.PrepareVersionDownload(userID, c.Param("id"), c.Param("versionId"))
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to download file version")
+		return
+	}
+
+	WriteDownload(c, download)
+}
+
+// PromoteFileVersionHandler makes an older version the current version of a file
+func PromoteFileVersionHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	file, err := fileService.PromoteVersion(userID, c.Param("id"), c.Param("versionId"))
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to promote file version")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"file":    file,
+	})
+}
+
+// PruneFileVersionsHandler deletes old versions of a file by count and/or age
+func PruneFileVersionsHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body fileservice.PruneVersionsRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	fileService := fileservice.NewFileService()
+	response, err := fileService.PruneVersions(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFileError(c, err, "Failed to prune file versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// WriteDownload streams a prepared download as an attachment.
+// Once the body has started the status can no longer change, so mid-stream failures are only logged.
+func WriteDownload(c *gin.Context, download *fileservice.Download) {
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(download.Size, 10))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", download.File.Name))
+	c.Status(http.StatusOK)
+
+	if _, err := download.WriteTo(c.Writer); err != nil {
+		log.Printf("Failed to stream file %s: %v", download.File.ID, err)
+		c.Abort()
+	}
+}
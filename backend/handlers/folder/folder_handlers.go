@@ -0,0 +1,213 @@
+package folder
+
+import (
+	"net/http"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	folderservice "github.com/AnshJain-Shwalia/DataHub/backend/services/folder"
+	"github.com/gin-gonic/gin"
+)
+
+// folderErrorStatus maps folder error codes to HTTP status codes
+func folderErrorStatus(code string) int {
+	switch code {
+	case "INVALID_NAME", "INVALID_CONFLICT_POLICY", "PARENT_NOT_FOUND", "DESTINATION_NOT_FOUND", "INVALID_MOVE", "INVALID_SORT", "INVALID_CURSOR", "INVALID_FILTER":
+		return http.StatusBadRequest
+	case "FOLDER_NOT_FOUND":
+		return http.StatusNotFound
+	case "NAME_CONFLICT":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RespondWithFolderError writes an error returned by the FolderService to the response
+func RespondWithFolderError(c *gin.Context, err error, fallbackMessage string) {
+	if folderErr, ok := err.(*folderservice.FolderError); ok {
+		status := folderErrorStatus(folderErr.Code)
+		c.JSON(status, http_util.NewErrorResponse(status, folderErr.Message, folderErr.Details))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, fallbackMessage, err.Error()))
+}
+
+// CreateFolderHandler creates a new folder for the authenticated user.
+// The actual business logic is handled by the FolderService.
+func CreateFolderHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body folderservice.CreateFolderRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	folderService := folderservice.NewFolderService()
+	folder, err := folderService.CreateFolder(userID, &body)
+	if err != nil {
+		RespondWithFolderError(c, err, "Failed to create folder")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"folder":  folder,
+	})
+}
+
+// GetFolderHandler returns a single folder owned by the authenticated user
+func GetFolderHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	folderService := folderservice.NewFolderService()
+	folder, err := folderService.GetFolder(userID, c.Param("id"))
+	if err != nil {
+		RespondWithFolderError(c, err, "Failed to retrieve folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"folder":  folder,
+	})
+}
+
+// RenameFolderHandler renames a folder owned by the authenticated user
+func RenameFolderHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body folderservice.RenameFolderRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	folderService := folderservice.NewFolderService()
+	folder, err := folderService.RenameFolder(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFolderError(c, err, "Failed to rename folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"folder":  folder,
+	})
+}
+
+// MoveFolderHandler moves (and optionally renames) a folder owned by the authenticated user
+func MoveFolderHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body folderservice.MoveFolderRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	folderService := folderservice.NewFolderService()
+	folder, err := folderService.MoveFolder(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithFolderError(c, err, "Failed to move folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"folder":  folder,
+	})
+}
+
+// DeleteFolderHandler moves a folder owned by the authenticated user, and its contents, to the trash
+func DeleteFolderHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	folderService := folderservice.NewFolderService()
+	if err := folderService.DeleteFolder(userID, c.Param("id")); err != nil {
+		RespondWithFolderError(c, err, "Failed to delete folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Folder moved to trash",
+	})
+}
+
+// ListFolderChildrenHandler returns a page of the subfolders and files inside a folder
+func ListFolderChildrenHandler(c *gin.Context) {
+	folderID := c.Param("id")
+	listChildren(c, &folderID)
+}
+
+// ListRootChildrenHandler returns a page of the subfolders and files at the user's root
+func ListRootChildrenHandler(c *gin.Context) {
+	listChildren(c, nil)
+}
+
+// GetRootStatsHandler returns the total size and number of items in the authenticated user's folder tree
+func GetRootStatsHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	folderService := folderservice.NewFolderService()
+	stats, err := folderService.GetRootStats(userID)
+	if err != nil {
+		RespondWithFolderError(c, err, "Failed to compute folder size")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"stats":   stats,
+	})
+}
+
+// listChildren handles both the folder and the root listing; a nil folderID lists the root
+func listChildren(c *gin.Context, folderID *string) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var query folderservice.ListChildrenRequest
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Invalid query parameters", err.Error()))
+		return
+	}
+
+	folderService := folderservice.NewFolderService()
+	response, err := folderService.ListChildren(userID, folderID, &query)
+	if err != nil {
+		RespondWithFolderError(c, err, "Failed to list folder contents")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
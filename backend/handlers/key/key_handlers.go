@@ -0,0 +1,80 @@
+package key
+
+import (
+	"net/http"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	keyservice "github.com/AnshJain-Shwalia/DataHub/backend/services/key"
+	"github.com/gin-gonic/gin"
+)
+
+// keyErrorStatus maps key error codes to HTTP status codes
+func keyErrorStatus(code string) int {
+	switch code {
+	case "INVALID_KEY":
+		return http.StatusBadRequest
+	case "KEY_NOT_FOUND":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RespondWithKeyError writes an error returned by the KeyService to the response
+func RespondWithKeyError(c *gin.Context, err error, fallbackMessage string) {
+	if keyErr, ok := err.(*keyservice.KeyError); ok {
+		status := keyErrorStatus(keyErr.Code)
+		c.JSON(status, http_util.NewErrorResponse(status, keyErr.Message, keyErr.Details))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, fallbackMessage, err.Error()))
+}
+
+// GetKeyHandler returns the wrapped end-to-end encryption key of the authenticated user
+func GetKeyHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	keyService := keyservice.NewKeyService()
+	key, err := keyService.GetKey(userID)
+	if err != nil {
+		RespondWithKeyError(c, err, "Failed to retrieve key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"key":     key,
+	})
+}
+
+// SaveKeyHandler stores or re-wraps the end-to-end encryption key of the authenticated user
+func SaveKeyHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body keyservice.SaveKeyRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	keyService := keyservice.NewKeyService()
+	key, err := keyService.SaveKey(userID, &body)
+	if err != nil {
+		RespondWithKeyError(c, err, "Failed to save key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"key":     key,
+	})
+}
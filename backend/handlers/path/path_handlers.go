@@ -0,0 +1,136 @@
+package path
+
+import (
+	"net/http"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/file"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/folder"
+	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	folderservice "github.com/AnshJain-Shwalia/DataHub/backend/services/folder"
+	pathservice "github.com/AnshJain-Shwalia/DataHub/backend/services/path"
+	"github.com/gin-gonic/gin"
+)
+
+// pathErrorStatus maps path error codes to HTTP status codes
+func pathErrorStatus(code string) int {
+	switch code {
+	case "INVALID_PATH", "NOT_A_FOLDER", "NOT_A_FILE":
+		return http.StatusBadRequest
+	case "PATH_NOT_FOUND":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondWithPathError writes an error returned by the PathService to the response.
+// Errors raised by the underlying folder and file services are mapped by their own handlers.
+func respondWithPathError(c *gin.Context, err error, fallbackMessage string) {
+	switch typedErr := err.(type) {
+	case *pathservice.PathError:
+		status := pathErrorStatus(typedErr.Code)
+		c.JSON(status, http_util.NewErrorResponse(status, typedErr.Message, typedErr.Details))
+	case *folderservice.FolderError:
+		folder.RespondWithFolderError(c, err, fallbackMessage)
+	default:
+		file.RespondWithFileError(c, err, fallbackMessage)
+	}
+}
+
+// requirePath extracts the user ID and the "path" query parameter, writing an error response
+// if either is missing
+func requirePath(c *gin.Context) (string, string, bool) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return "", "", false
+	}
+
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "path query parameter is required", nil))
+		return "", "", false
+	}
+	return userID, path, true
+}
+
+// StatHandler describes the folder or file at a path
+func StatHandler(c *gin.Context) {
+	userID, path, ok := requirePath(c)
+	if !ok {
+		return
+	}
+
+	pathService := pathservice.NewPathService()
+	response, err := pathService.Stat(userID, path)
+	if err != nil {
+		respondWithPathError(c, err, "Failed to resolve path")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListHandler returns a page of the contents of the folder at a path
+func ListHandler(c *gin.Context) {
+	userID, path, ok := requirePath(c)
+	if !ok {
+		return
+	}
+
+	var query folderservice.ListChildrenRequest
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Invalid query parameters", err.Error()))
+		return
+	}
+
+	pathService := pathservice.NewPathService()
+	response, err := pathService.List(userID, path, &query)
+	if err != nil {
+		respondWithPathError(c, err, "Failed to list folder contents")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DownloadHandler streams the content of the file at a path
+func DownloadHandler(c *gin.Context) {
+	userID, path, ok := requirePath(c)
+	if !ok {
+		return
+	}
+
+	pathService := pathservice.NewPathService()
+	download, err := pathService.PrepareDownload(userID, path)
+	if err != nil {
+		respondWithPathError(c, err, "Failed to download file")
+		return
+	}
+
+	file.WriteDownload(c, download)
+}
+
+// UploadHandler starts uploading a new file at a path and returns pre-signed chunk upload URLs
+func UploadHandler(c *gin.Context) {
+	userID, path, ok := requirePath(c)
+	if !ok {
+		return
+	}
+
+	var body pathservice.UploadToPathRequest
+	if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+		return
+	}
+
+	pathService := pathservice.NewPathService()
+	response, err := pathService.InitiateUpload(userID, path, &body)
+	if err != nil {
+		respondWithPathError(c, err, "Failed to start upload")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"net/http"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	searchservice "github.com/AnshJain-Shwalia/DataHub/backend/services/search"
+	"github.com/gin-gonic/gin"
+)
+
+// searchErrorStatus maps search error codes to HTTP status codes
+func searchErrorStatus(code string) int {
+	switch code {
+	case "INVALID_QUERY":
+		return http.StatusBadRequest
+	case "FOLDER_NOT_FOUND":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RespondWithSearchError writes an error returned by the SearchService to the response
+func RespondWithSearchError(c *gin.Context, err error, fallbackMessage string) {
+	if searchErr, ok := err.(*searchservice.SearchError); ok {
+		status := searchErrorStatus(searchErr.Code)
+		c.JSON(status, http_util.NewErrorResponse(status, searchErr.Message, searchErr.Details))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, fallbackMessage, err.Error()))
+}
+
+// SearchHandler searches the authenticated user's files and folders by name
+func SearchHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var query searchservice.SearchRequest
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Invalid query parameters", err.Error()))
+		return
+	}
+
+	searchService := searchservice.NewSearchService()
+	response, err := searchService.Search(userID, &query)
+	if err != nil {
+		RespondWithSearchError(c, err, "Failed to search")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
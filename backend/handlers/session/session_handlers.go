@@ -0,0 +1,116 @@
+package session
+
+import (
+	"net/http"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	sessionservice "github.com/AnshJain-Shwalia/DataHub/backend/services/session"
+	"github.com/gin-gonic/gin"
+)
+
+// sessionErrorStatus maps session error codes to HTTP status codes
+func sessionErrorStatus(code string) int {
+	switch code {
+	case "SESSION_NOT_FOUND":
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RespondWithSessionError writes an error returned by the SessionService to the response
+func RespondWithSessionError(c *gin.Context, err error, fallbackMessage string) {
+	if sessionErr, ok := err.(*sessionservice.SessionError); ok {
+		status := sessionErrorStatus(sessionErr.Code)
+		c.JSON(status, http_util.NewErrorResponse(status, sessionErr.Message, sessionErr.Details))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, fallbackMessage, err.Error()))
+}
+
+// ListSessionsHandler lists the active sign-in sessions of the authenticated user
+func ListSessionsHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	sessionService := sessionservice.NewSessionService()
+	sessions, err := sessionService.ListSessions(userID, middleware.GetSessionIDFromContext(c))
+	if err != nil {
+		RespondWithSessionError(c, err, "Failed to retrieve sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"sessions": sessions,
+	})
+}
+
+// RevokeSessionHandler signs the authenticated user out of one of their sessions
+func RevokeSessionHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	sessionService := sessionservice.NewSessionService()
+	if err := sessionService.RevokeSession(userID, c.Param("id")); err != nil {
+		RespondWithSessionError(c, err, "Failed to revoke session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RevokeAllSessionsHandler signs the authenticated user out of all of their sessions.
+// With ?exceptCurrent=true the session making the request stays signed in.
+func RevokeAllSessionsHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	exceptSessionID := ""
+	if c.Query("exceptCurrent") == "true" {
+		exceptSessionID = middleware.GetSessionIDFromContext(c)
+	}
+
+	sessionService := sessionservice.NewSessionService()
+	revoked, err := sessionService.RevokeAllSessions(userID, exceptSessionID)
+	if err != nil {
+		RespondWithSessionError(c, err, "Failed to revoke sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"revoked": revoked,
+	})
+}
+
+// LogoutHandler signs the authenticated user out of the session making the request
+func LogoutHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	sessionService := sessionservice.NewSessionService()
+	if err := sessionService.RevokeSession(userID, middleware.GetSessionIDFromContext(c)); err != nil {
+		RespondWithSessionError(c, err, "Failed to log out")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
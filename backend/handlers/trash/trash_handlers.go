@@ -0,0 +1,79 @@
+package trash
+
+import (
+	"net/http"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	trashservice "github.com/AnshJain-Shwalia/DataHub/backend/services/trash"
+	"github.com/gin-gonic/gin"
+)
+
+// trashErrorStatus maps trash error codes to HTTP status codes
+func trashErrorStatus(code string) int {
+	switch code {
+	case "INVALID_CONFLICT_POLICY":
+		return http.StatusBadRequest
+	case "TRASH_ITEM_NOT_FOUND":
+		return http.StatusNotFound
+	case "NAME_CONFLICT":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RespondWithTrashError writes an error returned by the TrashService to the response
+func RespondWithTrashError(c *gin.Context, err error, fallbackMessage string) {
+	if trashErr, ok := err.(*trashservice.TrashError); ok {
+		status := trashErrorStatus(trashErr.Code)
+		c.JSON(status, http_util.NewErrorResponse(status, trashErr.Message, trashErr.Details))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, fallbackMessage, err.Error()))
+}
+
+// ListTrashHandler returns the files and folders the authenticated user has deleted
+func ListTrashHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	trashService := trashservice.NewTrashService()
+	response, err := trashService.ListTrash(userID)
+	if err != nil {
+		RespondWithTrashError(c, err, "Failed to retrieve trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreTrashItemHandler restores a trashed file or folder of the authenticated user.
+// The request body is optional and only carries the conflict policy.
+func RestoreTrashItemHandler(c *gin.Context) {
+	userID := middleware.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "User ID not found in token", nil))
+		return
+	}
+
+	var body trashservice.RestoreRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, http_util.NewErrorResponse(http.StatusBadRequest, "Incorrect body structure", err.Error()))
+			return
+		}
+	}
+
+	trashService := trashservice.NewTrashService()
+	response, err := trashService.Restore(userID, c.Param("id"), &body)
+	if err != nil {
+		RespondWithTrashError(c, err, "Failed to restore item")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
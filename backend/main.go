@@ -7,7 +7,18 @@ import (
 	"github.com/AnshJain-Shwalia/DataHub/backend/config"
 	"github.com/AnshJain-Shwalia/DataHub/backend/db"
 	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/auth"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/file"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/folder"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/key"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/path"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/search"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/session"
+	"github.com/AnshJain-Shwalia/DataHub/backend/handlers/trash"
 	"github.com/AnshJain-Shwalia/DataHub/backend/middleware"
+	authservice "github.com/AnshJain-Shwalia/DataHub/backend/services/auth"
+	compactionservice "github.com/AnshJain-Shwalia/DataHub/backend/services/compaction"
+	fileservice "github.com/AnshJain-Shwalia/DataHub/backend/services/file"
+	trashservice "github.com/AnshJain-Shwalia/DataHub/backend/services/trash"
 	"github.com/gin-gonic/gin"
 )
 
@@ -31,6 +42,18 @@ func main() {
 	}
 	log.Println("Database migrations completed")
 	
+	log.Printf("Starting OAuth state cleanup worker (store: %s)...", cfg.OAuthStateStore)
+	authservice.StartStateCleanupWorker()
+	
+	log.Printf("Starting content hash worker (interval: %d minutes)...", cfg.ContentHashIntervalMinutes)
+	fileservice.NewFileService().StartHashWorker()
+	
+	log.Printf("Starting trash purge worker (retention: %d days)...", cfg.TrashRetentionDays)
+	trashservice.NewTrashService().StartPurgeWorker()
+	
+	log.Printf("Starting repository compaction worker (interval: %d minutes)...", cfg.CompactionIntervalMinutes)
+	compactionservice.NewCompactionService().StartCompactionWorker()
+	
 	log.Println("Setting up routes...")
 	router := gin.Default()
 	
@@ -47,6 +70,12 @@ func main() {
 		// TTBD: Temporary development endpoint - remove before production
 		authGroup.GET("/signin-by-id/:id", auth.GetSigninTokenByIDHandler)
 		
+		// Session renewal: exchanges a refresh token for a new access token
+		authGroup.POST("/refresh", auth.RefreshTokenHandler)
+		
+		// Logout: revokes the session of the presented access token
+		authGroup.POST("/logout", middleware.RequireJWT(), session.LogoutHandler)
+		
 		// Google OAuth routes
 		googleGroup := authGroup.Group("/google")
 		{
@@ -61,9 +90,93 @@ func main() {
 			githubGroup.Use(middleware.RequireJWT())
 			githubGroup.POST("/accounts", auth.AddGitHubAccountHandler)
 			githubGroup.GET("/accounts", auth.GetGitHubAccountsHandler)
+			githubGroup.DELETE("/accounts/:login", auth.RemoveGitHubAccountHandler)
 			githubGroup.GET("/oauth-url", auth.GenerateGitHubOAuthURLHandler)
+			
+			// GitHub App installations, linked as storage accounts alongside OAuth accounts
+			githubGroup.GET("/app/install-url", auth.GenerateGitHubAppInstallURLHandler)
+			githubGroup.POST("/installations", auth.AddGitHubInstallationHandler)
+			githubGroup.DELETE("/installations/:account", auth.RemoveGitHubInstallationHandler)
 		}
 	}
+
+	// Folder management routes (scoped to the authenticated user)
+	folderGroup := router.Group("/folders")
+	{
+		folderGroup.Use(middleware.RequireJWT())
+		folderGroup.POST("/", folder.CreateFolderHandler)
+		folderGroup.GET("/root/children", folder.ListRootChildrenHandler)
+		folderGroup.GET("/root/stats", folder.GetRootStatsHandler)
+		folderGroup.GET("/:id", folder.GetFolderHandler)
+		folderGroup.GET("/:id/children", folder.ListFolderChildrenHandler)
+		folderGroup.PATCH("/:id", folder.RenameFolderHandler)
+		folderGroup.POST("/:id/move", folder.MoveFolderHandler)
+		folderGroup.DELETE("/:id", folder.DeleteFolderHandler)
+	}
+
+	// File metadata routes (scoped to the authenticated user)
+	fileGroup := router.Group("/files")
+	{
+		fileGroup.Use(middleware.RequireJWT())
+		fileGroup.GET("", file.FindFilesHandler)
+		fileGroup.POST("/uploads", file.InitiateUploadHandler)
+		fileGroup.GET("/:id", file.GetFileHandler)
+		fileGroup.GET("/:id/download", file.DownloadFileHandler)
+		fileGroup.GET("/:id/thumbnail", file.GetThumbnailHandler)
+		fileGroup.POST("/:id/finalize", file.FinalizeUploadHandler)
+		fileGroup.PATCH("/:id", file.RenameFileHandler)
+		fileGroup.POST("/:id/move", file.MoveFileHandler)
+		fileGroup.POST("/:id/copy", file.CopyFileHandler)
+		fileGroup.DELETE("/:id", file.DeleteFileHandler)
+		fileGroup.PUT("/:id/tags", file.SetFileTagsHandler)
+		fileGroup.PUT("/:id/metadata", file.SetFileMetadataHandler)
+		fileGroup.GET("/:id/versions", file.ListFileVersionsHandler)
+		fileGroup.POST("/:id/versions/prune", file.PruneFileVersionsHandler)
+		fileGroup.GET("/:id/versions/:versionId/download", file.DownloadFileVersionHandler)
+		fileGroup.POST("/:id/versions/:versionId/promote", file.PromoteFileVersionHandler)
+	}
+
+	// Path-based addressing of the user's folders and files, e.g. ?path=/photos/2024/trip.mp4
+	pathGroup := router.Group("/paths")
+	{
+		pathGroup.Use(middleware.RequireJWT())
+		pathGroup.GET("/stat", path.StatHandler)
+		pathGroup.GET("/list", path.ListHandler)
+		pathGroup.GET("/download", path.DownloadHandler)
+		pathGroup.POST("/upload", path.UploadHandler)
+	}
+
+	// Name search over the user's files and folders, e.g. ?q=report&type=file&folderId=...
+	searchGroup := router.Group("/search")
+	{
+		searchGroup.Use(middleware.RequireJWT())
+		searchGroup.GET("", search.SearchHandler)
+	}
+
+	// Key routes: wrapped end-to-end encryption keys, opaque to the server
+	keyGroup := router.Group("/keys")
+	{
+		keyGroup.Use(middleware.RequireJWT())
+		keyGroup.GET("", key.GetKeyHandler)
+		keyGroup.PUT("", key.SaveKeyHandler)
+	}
+
+	// Session routes: the user's sign-ins, which can be revoked individually or all at once
+	sessionGroup := router.Group("/sessions")
+	{
+		sessionGroup.Use(middleware.RequireJWT())
+		sessionGroup.GET("", session.ListSessionsHandler)
+		sessionGroup.DELETE("", session.RevokeAllSessionsHandler)
+		sessionGroup.DELETE("/:id", session.RevokeSessionHandler)
+	}
+
+	// Trash routes: deleted files and folders are kept here until the retention period elapses
+	trashGroup := router.Group("/trash")
+	{
+		trashGroup.Use(middleware.RequireJWT())
+		trashGroup.GET("/", trash.ListTrashHandler)
+		trashGroup.POST("/:id/restore", trash.RestoreTrashItemHandler)
+	}
 	
 	log.Printf("Server starting on port %d...", cfg.Port)
 	log.Printf("Server running at http://localhost:%d", cfg.Port)
@@ -1,16 +1,26 @@
 package middleware
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/AnshJain-Shwalia/DataHub/backend/config"
 	"github.com/AnshJain-Shwalia/DataHub/backend/http_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// sessionTouchInterval limits how often a session's last seen time is written back
+const sessionTouchInterval = time.Minute
+
 // JWTClaims represents the structure of our JWT claims
+// The embedded RegisteredClaims.ID holds the jti claim, the ID of the token's session
 type JWTClaims struct {
 	ID    string `json:"id"`
 	Email string `json:"email"`
@@ -18,7 +28,8 @@ type JWTClaims struct {
 }
 
 // RequireJWT is a middleware that validates JWT tokens and extracts user information
-// It adds the user ID to the gin context for downstream handlers to use
+// It rejects tokens whose session has been revoked or has expired, and adds the user ID
+// and session ID to the gin context for downstream handlers to use
 func RequireJWT() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract Authorization header
@@ -61,9 +72,34 @@ func RequireJWT() gin.HandlerFunc {
 
 		// Extract claims
 		if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+			// The token is only honoured while its session is active
+			sessionID := claims.RegisteredClaims.ID
+			if uuid.Validate(sessionID) != nil {
+				c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "JWT token has no valid session", nil))
+				c.Abort()
+				return
+			}
+			session, err := repositories.FindActiveSession(sessionID, claims.ID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "Session has been revoked or has expired", nil))
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, http_util.NewErrorResponse(http.StatusInternalServerError, "Failed to verify session", err.Error()))
+				c.Abort()
+				return
+			}
+			if time.Since(session.LastSeenAt) > sessionTouchInterval {
+				if err := repositories.TouchSession(session.ID, c.ClientIP()); err != nil {
+					log.Printf("Failed to update last seen time of session %s: %v", session.ID, err)
+				}
+			}
+
 			// Add user information to context for downstream handlers
 			c.Set("userID", claims.ID)
 			c.Set("userEmail", claims.Email)
+			c.Set("sessionID", sessionID)
 			c.Next()
 		} else {
 			c.JSON(http.StatusUnauthorized, http_util.NewErrorResponse(http.StatusUnauthorized, "Invalid JWT claims", nil))
@@ -93,4 +129,15 @@ func GetUserEmailFromContext(c *gin.Context) string {
 		}
 	}
 	return ""
+}
+
+// GetSessionIDFromContext is a helper function to extract the session ID from gin context
+// Returns empty string if the session ID is not found
+func GetSessionIDFromContext(c *gin.Context) string {
+	if sessionID, exists := c.Get("sessionID"); exists {
+		if id, ok := sessionID.(string); ok {
+			return id
+		}
+	}
+	return ""
 }
\ No newline at end of file
@@ -3,10 +3,13 @@ package models
 import "time"
 
 type Branch struct {
-	ID        string    `gorm:"primaryKey;type:uuid"`
-	Name      string    `gorm:"column:name;type:text;not null"`
-	RepoID    string    `gorm:"column:repo_id;type:uuid;not null;index"`
-	Repo      Repo      `gorm:"foreignKey:RepoID;references:ID"`
-	Chunks    []Chunk   `gorm:"-"`
-	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+	ID     string `gorm:"primaryKey;type:uuid"`
+	Name   string `gorm:"column:name;type:text;not null"`
+	RepoID string `gorm:"column:repo_id;type:uuid;not null;index"`
+	Repo   Repo   `gorm:"foreignKey:RepoID;references:ID"`
+	// ReclaimableBytes counts deleted chunk data that is still kept in the branch's Git history
+	ReclaimableBytes int64      `gorm:"column:reclaimable_bytes;type:bigint;not null;default:0"`
+	CompactedAt      *time.Time `gorm:"column:compacted_at;type:timestamptz"`
+	Chunks           []Chunk    `gorm:"-"`
+	CreatedAt        time.Time  `gorm:"column:created_at;type:timestamptz;not null"`
 }
@@ -2,17 +2,31 @@ package models
 
 import "time"
 
+// Chunk lifecycle statuses
+const (
+	ChunkStatusPending  = "PENDING"  // created, waiting for the client to upload it to the S3 buffer
+	ChunkStatusBuffered = "BUFFERED" // uploaded to the S3 buffer, not yet pushed to GitHub
+	ChunkStatusPushed   = "PUSHED"   // stored in a GitHub repository branch
+	ChunkStatusFailed   = "FAILED"   // pushing to GitHub failed
+)
+
+// Chunk is one piece of a file version's content. Copies of a file get their own chunk records that
+// point at the same stored blobs, so a blob is only deleted once no chunk references it any more.
+// Uploaded chunks whose hash matches content the user already stores reuse that blob too.
 type Chunk struct {
-	ID        string     `gorm:"primaryKey;type:uuid"`
-	FileID    string     `gorm:"column:file_id;type:uuid;not null;index"`
-	File      File       `gorm:"foreignKey:FileID;references:ID"`
-	Rank      int        `gorm:"column:rank;type:int;not null"`
-	Size      int64      `gorm:"column:size;type:bigint;not null"`
-	S3Path    *string    `gorm:"column:s3_path;type:text"`
-	GitPath   *string    `gorm:"column:git_path;type:text"`
-	BranchID  *string    `gorm:"column:branch_id;type:uuid;index"`
-	Branch    *Branch    `gorm:"foreignKey:BranchID;references:ID"`
-	Status    string     `gorm:"column:status;type:varchar(20);not null;default:'BUFFERED'"`
-	CreatedAt time.Time  `gorm:"column:created_at;type:timestamptz;not null"`
-	UpdatedAt time.Time  `gorm:"column:updated_at;type:timestamptz;not null"`
+	ID        string       `gorm:"primaryKey;type:uuid"`
+	FileID    string       `gorm:"column:file_id;type:uuid;not null;index"`
+	File      File         `gorm:"foreignKey:FileID;references:ID"`
+	VersionID *string      `gorm:"column:version_id;type:uuid;index"`
+	Version   *FileVersion `gorm:"foreignKey:VersionID;references:ID"`
+	Rank      int          `gorm:"column:rank;type:int;not null"`
+	Size      int64        `gorm:"column:size;type:bigint;not null;index:idx_chunks_hash_size,priority:2"`
+	Hash      *string      `gorm:"column:hash;type:char(64);index:idx_chunks_hash_size,priority:1"` // hex SHA-256 of the chunk content, computed by the server after the upload completes
+	S3Path    *string      `gorm:"column:s3_path;type:text;index"`
+	GitPath   *string      `gorm:"column:git_path;type:text"`
+	BranchID  *string      `gorm:"column:branch_id;type:uuid;index"`
+	Branch    *Branch      `gorm:"foreignKey:BranchID;references:ID"`
+	Status    string       `gorm:"column:status;type:varchar(20);not null;default:'BUFFERED'"`
+	CreatedAt time.Time    `gorm:"column:created_at;type:timestamptz;not null"`
+	UpdatedAt time.Time    `gorm:"column:updated_at;type:timestamptz;not null"`
 }
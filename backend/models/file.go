@@ -1,15 +1,33 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// File upload statuses
+const (
+	FileStatusUploading = "UPLOADING" // metadata created, chunks still being uploaded
+	FileStatusComplete  = "COMPLETE"  // every chunk has been uploaded
+)
 
 type File struct {
-	ID        string    `gorm:"primaryKey;type:uuid"`
-	Name      string    `gorm:"column:name;type:text;not null"`
-	FolderID  *string   `gorm:"column:folder_id;type:uuid"`
-	Folder    *Folder   `gorm:"foreignKey:FolderID;references:ID"`
-	Size      int64     `gorm:"column:size;type:bigint;not null"`
-	UserID    string    `gorm:"column:user_id;type:uuid;not null;index"`
-	User      User      `gorm:"foreignKey:UserID;references:ID"`
-	Chunks    []Chunk   `gorm:"-"`
-	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+	ID               string         `gorm:"primaryKey;type:uuid"`
+	Name             string         `gorm:"column:name;type:text;not null"`
+	FolderID         *string        `gorm:"column:folder_id;type:uuid;index"`
+	Folder           *Folder        `gorm:"foreignKey:FolderID;references:ID"`
+	Size             int64          `gorm:"column:size;type:bigint;not null"` // size of the current version
+	Status           string         `gorm:"column:status;type:varchar(20);not null;default:'COMPLETE'"`
+	ContentType      *string        `gorm:"column:content_type;type:varchar(255)"` // content type of the current version
+	UserID           string         `gorm:"column:user_id;type:uuid;not null;index"`
+	User             User           `gorm:"foreignKey:UserID;references:ID"`
+	Chunks           []Chunk        `gorm:"-"`
+	CurrentVersionID *string        `gorm:"column:current_version_id;type:uuid"`                         // the version served on download
+	ClientEncrypted  bool           `gorm:"column:client_encrypted;type:boolean;not null;default:false"` // content is encrypted end-to-end and never inspected by the server
+	ClientWrappedKey *string        `gorm:"column:client_wrapped_key;type:text"`                         // the file's data key wrapped by the user's key, opaque to the server
+	CreatedAt        time.Time      `gorm:"column:created_at;type:timestamptz;not null"`
+	DeletedAt        gorm.DeletedAt `gorm:"column:deleted_at;type:timestamptz;index"` // set while the item is in the trash
+	TrashRootID      *string        `gorm:"column:trash_root_id;type:uuid;index"`     // the trashed item whose deletion put this row in the trash
+	OriginalPath     *string        `gorm:"column:original_path;type:text"`           // path of the item when it was trashed
 }
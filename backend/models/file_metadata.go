@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// FileMetadata is a user-defined key/value pair attached to a file, e.g. owner=alice
+type FileMetadata struct {
+	ID        string    `gorm:"primaryKey;type:uuid"`
+	FileID    string    `gorm:"column:file_id;type:uuid;not null;uniqueIndex:idx_file_metadata_file_key"`
+	File      File      `gorm:"foreignKey:FileID;references:ID"`
+	UserID    string    `gorm:"column:user_id;type:uuid;not null;index:idx_file_metadata_user_key"`
+	Key       string    `gorm:"column:key;type:varchar(64);not null;uniqueIndex:idx_file_metadata_file_key;index:idx_file_metadata_user_key"`
+	Value     string    `gorm:"column:value;type:text;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamptz;not null"`
+}
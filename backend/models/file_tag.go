@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// FileTag is a user-defined label attached to a file, e.g. "project-apollo"
+type FileTag struct {
+	ID        string    `gorm:"primaryKey;type:uuid"`
+	FileID    string    `gorm:"column:file_id;type:uuid;not null;uniqueIndex:idx_file_tags_file_tag"`
+	File      File      `gorm:"foreignKey:FileID;references:ID"`
+	UserID    string    `gorm:"column:user_id;type:uuid;not null;index:idx_file_tags_user_tag"`
+	Tag       string    `gorm:"column:tag;type:varchar(64);not null;uniqueIndex:idx_file_tags_file_tag;index:idx_file_tags_user_tag"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// FileVersion is one uploaded revision of a file's content. A file's chunks belong to
+// exactly one version, and the file points at the version that is currently served.
+type FileVersion struct {
+	ID           string    `gorm:"primaryKey;type:uuid"`
+	FileID       string    `gorm:"column:file_id;type:uuid;not null;uniqueIndex:idx_file_versions_number"`
+	File         File      `gorm:"foreignKey:FileID;references:ID"`
+	Number       int       `gorm:"column:number;type:int;not null;uniqueIndex:idx_file_versions_number"` // 1 for the first upload, increasing with every re-upload
+	Size         int64     `gorm:"column:size;type:bigint;not null"`
+	Hash         *string   `gorm:"column:hash;type:char(64);index"`                            // hex SHA-256 of the whole content, computed by the server after the upload completes
+	Status       string    `gorm:"column:status;type:varchar(20);not null;default:'COMPLETE'"` // FileStatusUploading or FileStatusComplete
+	ContentType  *string   `gorm:"column:content_type;type:varchar(255)"`                      // sniffed from the first chunk when the upload is finalized
+	ThumbnailKey *string   `gorm:"column:thumbnail_key;type:text"`                             // S3 key of the generated thumbnail, for images only
+	Chunks       []Chunk   `gorm:"-"`
+	CreatedAt    time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+}
@@ -1,15 +1,22 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Folder struct {
-	ID             string    `gorm:"primaryKey;type:uuid"`
-	Name           string    `gorm:"column:name;type:text;not null"`
-	ParentFolderID *string   `gorm:"column:parent_folder_id;type:uuid"`
-	ParentFolder   *Folder   `gorm:"foreignKey:ParentFolderID;references:ID"`
-	UserID         string    `gorm:"column:user_id;type:uuid;not null;index"`
-	User           User      `gorm:"foreignKey:UserID;references:ID"`
-	Files          []File    `gorm:"-"`
-	Subfolders     []Folder  `gorm:"-"`
-	CreatedAt      time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+	ID             string         `gorm:"primaryKey;type:uuid"`
+	Name           string         `gorm:"column:name;type:text;not null"`
+	ParentFolderID *string        `gorm:"column:parent_folder_id;type:uuid;index"`
+	ParentFolder   *Folder        `gorm:"foreignKey:ParentFolderID;references:ID"`
+	UserID         string         `gorm:"column:user_id;type:uuid;not null;index"`
+	User           User           `gorm:"foreignKey:UserID;references:ID"`
+	Files          []File         `gorm:"-"`
+	Subfolders     []Folder       `gorm:"-"`
+	CreatedAt      time.Time      `gorm:"column:created_at;type:timestamptz;not null"`
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at;type:timestamptz;index"` // set while the item is in the trash
+	TrashRootID    *string        `gorm:"column:trash_root_id;type:uuid;index"`     // the trashed item whose deletion put this row in the trash
+	OriginalPath   *string        `gorm:"column:original_path;type:text"`           // path of the item when it was trashed
 }
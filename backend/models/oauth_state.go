@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// OAuthState is a pending OAuth2 state token, kept from generating an authorization URL until
+// the callback consumes it. A state is only accepted by the flow it was minted for and, for
+// flows started by a signed-in user, from that same user. The PKCE code verifier generated with
+// the state stays on the server until the code is exchanged. Each requester may only hold a few
+// pending states, and the oldest states are evicted once the table is full. Expired states are
+// deleted by the state cleanup worker.
+type OAuthState struct {
+	State        string    `gorm:"primaryKey;type:varchar(64)"`
+	Purpose      string    `gorm:"column:purpose;type:varchar(20);not null;default:''"`         // the flow the state was minted for, e.g. "GOOGLE_LOGIN" or "GITHUB_LINK"
+	UserID       *string   `gorm:"column:user_id;type:uuid"`                                    // the user who requested it; nil for sign-in flows
+	Requester    string    `gorm:"column:requester;type:varchar(64);not null;default:'';index"` // "user:<id>" or, for sign-in flows, "ip:<address>"; pending states are limited per requester
+	CodeVerifier string    `gorm:"column:code_verifier;type:varchar(128);not null;default:''"`  // PKCE verifier sent with the code exchange
+	ExpiresAt    time.Time `gorm:"column:expires_at;type:timestamptz;not null;index"`
+	CreatedAt    time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// RefreshToken is an opaque, single-use token that renews a user's short-lived access JWT.
+// Only the SHA-256 hash of the token is stored. Every refresh marks the presented token as used
+// and issues a new one for the same Session; a used token presented again means it was leaked,
+// so the whole session is revoked.
+type RefreshToken struct {
+	ID        string     `gorm:"primaryKey;type:uuid"`
+	UserID    string     `gorm:"column:user_id;type:uuid;not null;index"`
+	User      User       `gorm:"foreignKey:UserID;references:ID"`
+	FamilyID  string     `gorm:"column:family_id;type:uuid;not null;index"` // the Session the token belongs to
+	TokenHash string     `gorm:"column:token_hash;type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;type:timestamptz;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:timestamptz"`    // set when the token is exchanged for a new one
+	RevokedAt *time.Time `gorm:"column:revoked_at;type:timestamptz"` // set when the family is revoked
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamptz;not null"`
+}
@@ -8,6 +8,7 @@ type Repo struct {
 	TokenID   string    `gorm:"column:token_id;type:uuid;not null;index"`
 	Token     Token     `gorm:"foreignKey:TokenID;references:ID"`
 	Name      string    `gorm:"column:name;type:text;not null"`
+	UsedBytes int64     `gorm:"column:used_bytes;type:bigint;not null;default:0"` // bytes of chunk data currently stored in the repo
 	Branches  []Branch  `gorm:"-"`
 	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;not null"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Session is one sign-in of a user. Its ID is the jti claim of every access JWT issued for
+// it and the family ID of its refresh tokens, so revoking the session invalidates both.
+type Session struct {
+	ID         string     `gorm:"primaryKey;type:uuid"`
+	UserID     string     `gorm:"column:user_id;type:uuid;not null;index"`
+	User       User       `gorm:"foreignKey:UserID;references:ID"`
+	Device     string     `gorm:"column:device;type:text;not null"`              // User-Agent of the client that signed in
+	IPAddress  string     `gorm:"column:ip_address;type:varchar(45);not null"`   // last seen client IP
+	LastSeenAt time.Time  `gorm:"column:last_seen_at;type:timestamptz;not null"` // updated on use, at most once a minute
+	ExpiresAt  time.Time  `gorm:"column:expires_at;type:timestamptz;not null"`   // expiry of the newest refresh token
+	RevokedAt  *time.Time `gorm:"column:revoked_at;type:timestamptz"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:timestamptz;not null"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserKey is a user's end-to-end encryption key, wrapped on the client with a key derived from
+// the user's passphrase. The server only stores the wrapped key and the parameters the client
+// needs to derive the wrapping key again; it can never unwrap it.
+type UserKey struct {
+	ID         string    `gorm:"primaryKey;type:uuid"`
+	UserID     string    `gorm:"column:user_id;type:uuid;not null;uniqueIndex"`
+	User       User      `gorm:"foreignKey:UserID;references:ID"`
+	WrappedKey string    `gorm:"column:wrapped_key;type:text;not null"`      // opaque, base64-encoded by the client
+	Algorithm  string    `gorm:"column:algorithm;type:varchar(50);not null"` // how the key is wrapped, e.g. "AES-256-GCM"
+	KDF        string    `gorm:"column:kdf;type:varchar(50);not null"`       // how the wrapping key is derived, e.g. "argon2id"
+	KDFParams  string    `gorm:"column:kdf_params;type:text;not null"`       // opaque KDF parameters such as salt and cost, e.g. JSON
+	Version    int       `gorm:"column:version;type:int;not null;default:1"` // incremented every time the key is re-wrapped
+	CreatedAt  time.Time `gorm:"column:created_at;type:timestamptz;not null"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;type:timestamptz;not null"`
+}
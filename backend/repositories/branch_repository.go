@@ -0,0 +1,100 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+)
+
+// FindBranchesToCompact retrieves the branches whose Git history holds at least the given
+// amount of deleted chunk data, most reclaimable first
+// The repository and token of each branch are preloaded so the branch can be rewritten on GitHub
+//
+// Parameters:
+//   - minReclaimableBytes: The minimum number of reclaimable bytes a branch must have
+//   - limit: The maximum number of branches to return
+//
+// Returns:
+//   - A slice of Branch models
+//   - An error if the database operation fails
+func FindBranchesToCompact(minReclaimableBytes int64, limit int) ([]models.Branch, error) {
+	var branches []models.Branch
+	err := db.DB.Preload("Repo.Token").
+		Where("reclaimable_bytes > 0 AND reclaimable_bytes >= ?", minReclaimableBytes).
+		Order("reclaimable_bytes DESC").
+		Limit(limit).
+		Find(&branches).Error
+	return branches, err
+}
+
+// branchLockClass namespaces the advisory locks taken on storage branches
+const branchLockClass = 40
+
+// WithBranchLock runs fn while holding an exclusive lock on a storage branch. Everything that
+// commits to a branch, whether it writes chunk blobs and records them in the database or deletes
+// released blobs, as well as compaction, which rewrites the branch from the database, runs under
+// this lock, so compaction never sees a blob on the branch before the chunk rows referencing it
+// and never force-updates over a commit it has not seen. The lock is a transaction-level advisory lock,
+// so fn is free to use other transactions, including ones that update the branch row.
+//
+// Parameters:
+//   - branchID: The ID of the branch to lock
+//   - fn: The work to do while the lock is held
+//
+// Returns:
+//   - The error returned by fn
+//   - An error if the lock cannot be taken
+func WithBranchLock(branchID string, fn func() error) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", branchLockClass, branchID).Error; err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+// GetLiveChunkPaths retrieves the Git paths of a branch that are referenced by any chunk record,
+// whatever its status, so compaction keeps every blob the database still points at
+//
+// Parameters:
+//   - branchID: The ID of the branch
+//
+// Returns:
+//   - A slice of distinct Git paths
+//   - An error if the database operation fails
+func GetLiveChunkPaths(branchID string) ([]string, error) {
+	var paths []string
+	err := db.DB.Model(&models.Chunk{}).
+		Distinct("git_path").
+		Where("branch_id = ? AND git_path IS NOT NULL", branchID).
+		Pluck("git_path", &paths).Error
+	return paths, err
+}
+
+// CompleteBranchCompaction records that a branch has been rewritten without the blobs of deleted
+// chunks, and recalculates the storage used by its repository from the chunks it still holds
+//
+// Parameters:
+//   - branch: The compacted branch, as loaded before the compaction started
+//
+// Returns:
+//   - An error if the database operation fails
+func CompleteBranchCompaction(branch *models.Branch) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		// Chunks deleted while the compaction ran are still in the new history, so only the
+		// bytes known when it started have been reclaimed
+		if err := tx.Exec(`UPDATE branches SET reclaimable_bytes = GREATEST(reclaimable_bytes - ?, 0), compacted_at = ?
+			WHERE id = ?`, branch.ReclaimableBytes, time.Now(), branch.ID).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE repos SET used_bytes = (
+				SELECT COALESCE(SUM(blob.size), 0) FROM (
+					SELECT DISTINCT ON (c.branch_id, c.git_path) c.size FROM chunks c JOIN branches b ON b.id = c.branch_id
+					WHERE b.repo_id = repos.id AND c.status = ?
+				) blob
+			) WHERE id = ?`, models.ChunkStatusPushed, branch.RepoID).Error
+	})
+}
@@ -0,0 +1,137 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+)
+
+// GetChunksForVersion retrieves all chunks of a file version ordered by rank
+// The branch, repository and token of each chunk are preloaded so that pushed
+// chunks can be located on GitHub without further queries
+//
+// Parameters:
+//   - versionID: The ID of the file version whose chunks should be returned
+//
+// Returns:
+//   - A slice of Chunk models ordered by rank
+//   - An error if the database operation fails
+func GetChunksForVersion(versionID string) ([]models.Chunk, error) {
+	var chunks []models.Chunk
+	err := db.DB.Preload("Branch.Repo.Token").
+		Where("version_id = ?", versionID).
+		Order("rank ASC").
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// MarkChunksBuffered marks the given chunks as uploaded to the S3 buffer
+//
+// Parameters:
+//   - chunkIDs: The IDs of the chunks that have been uploaded
+//
+// Returns:
+//   - An error if the database operation fails
+func MarkChunksBuffered(chunkIDs []string) error {
+	if len(chunkIDs) == 0 {
+		return nil
+	}
+	return db.DB.Model(&models.Chunk{}).
+		Where("id IN ? AND status = ?", chunkIDs, models.ChunkStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.ChunkStatusBuffered,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// chunkContent identifies chunk data by its hash and size
+type chunkContent struct {
+	hash string
+	size int64
+}
+
+// findStoredChunksByHash looks up chunks of the user's live files whose content is stored, in the
+// S3 buffer or on GitHub, and whose hash is among the given hashes, returning one chunk per distinct
+// hash and size. Only the server sets chunk hashes, after hashing the stored content, so a match is
+// never based on a hash a client merely claimed. Chunks of trashed files are skipped, since the
+// purge worker is about to release their blobs.
+func findStoredChunksByHash(tx *gorm.DB, userID string, hashes []string) (map[chunkContent]*models.Chunk, error) {
+	found := make(map[chunkContent]*models.Chunk)
+	if len(hashes) == 0 {
+		return found, nil
+	}
+
+	var chunks []models.Chunk
+	err := tx.Raw(`SELECT DISTINCT ON (c.hash, c.size) c.* FROM chunks c JOIN files f ON f.id = c.file_id
+		WHERE f.user_id = ? AND f.deleted_at IS NULL AND c.status IN ? AND c.hash IN ?
+		ORDER BY c.hash, c.size, c.created_at ASC`, userID, []string{models.ChunkStatusBuffered, models.ChunkStatusPushed}, hashes).
+		Scan(&chunks).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range chunks {
+		found[chunkContent{hash: *chunks[i].Hash, size: chunks[i].Size}] = &chunks[i]
+	}
+	return found, nil
+}
+
+// ReleasedBlobs are the stored copies of chunk data that are no longer referenced once a set of
+// chunks is deleted
+type ReleasedBlobs struct {
+	S3Keys       []string       // S3 buffer objects
+	PushedChunks []models.Chunk // one chunk per GitHub blob, with Branch.Repo.Token preloaded
+	Thumbnails   []string       // S3 keys of the thumbnails of deleted versions
+}
+
+// findReleasedBlobs determines inside a transaction which blobs are no longer referenced once the
+// chunks selected by the chunkIDs subquery are deleted. The caller must hold the tree lock of the
+// chunks' owner and delete the chunks in the same transaction, so no copy, deduplicated chunk or
+// instant upload can start referencing a released blob in between.
+func findReleasedBlobs(tx *gorm.DB, chunkIDs *gorm.DB) (*ReleasedBlobs, error) {
+	released := &ReleasedBlobs{}
+	err := tx.Raw(`SELECT DISTINCT c.s3_path FROM chunks c
+		WHERE c.id IN (?) AND c.s3_path IS NOT NULL AND NOT EXISTS (
+			SELECT 1 FROM chunks o WHERE o.s3_path = c.s3_path AND o.id NOT IN (?)
+		)`, chunkIDs, chunkIDs).Scan(&released.S3Keys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Preload("Branch.Repo.Token").
+		Where("id IN (?)", tx.Raw(`SELECT DISTINCT ON (c.branch_id, c.git_path) c.id FROM chunks c
+			WHERE c.id IN (?) AND c.status = ? AND NOT EXISTS (
+				SELECT 1 FROM chunks o WHERE o.branch_id = c.branch_id AND o.git_path = c.git_path AND o.id NOT IN (?)
+			)`, chunkIDs, models.ChunkStatusPushed, chunkIDs)).
+		Find(&released.PushedChunks).Error
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// deleteChunks deletes chunk records inside a transaction and frees the capacity their
+// pushed blobs used in each repository. Blobs still referenced by chunks outside the
+// deleted set (copies of the same file) keep counting towards their repository. The freed
+// bytes are recorded as reclaimable on each branch until the branch is compacted.
+func deleteChunks(tx *gorm.DB, chunkIDs *gorm.DB) error {
+	if err := tx.Exec(`WITH released AS (
+			SELECT DISTINCT ON (c.branch_id, c.git_path) c.branch_id, c.size FROM chunks c
+			WHERE c.status = ? AND c.id IN (?) AND NOT EXISTS (
+				SELECT 1 FROM chunks o WHERE o.branch_id = c.branch_id AND o.git_path = c.git_path AND o.id NOT IN (?)
+			)
+		), freed AS (
+			UPDATE branches SET reclaimable_bytes = branches.reclaimable_bytes + usage.bytes
+			FROM (SELECT branch_id, SUM(size) AS bytes FROM released GROUP BY branch_id) usage
+			WHERE branches.id = usage.branch_id
+			RETURNING branches.repo_id, usage.bytes
+		)
+		UPDATE repos SET used_bytes = GREATEST(repos.used_bytes - usage.bytes, 0)
+		FROM (SELECT repo_id, SUM(bytes) AS bytes FROM freed GROUP BY repo_id) usage
+		WHERE repos.id = usage.repo_id`, models.ChunkStatusPushed, chunkIDs, chunkIDs).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN (?)", chunkIDs).Delete(&models.Chunk{}).Error
+}
@@ -0,0 +1,156 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SetFileTags replaces the tags of a file with the given set
+//
+// Parameters:
+//   - fileID: The ID of the file
+//   - userID: The ID of the user who owns the file
+//   - tags: The complete set of tags the file should have (already normalised and de-duplicated)
+//
+// Returns:
+//   - An error if the database operation fails
+func SetFileTags(fileID string, userID string, tags []string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("file_id = ?", fileID).Delete(&models.FileTag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		rows := make([]models.FileTag, 0, len(tags))
+		for _, tag := range tags {
+			rows = append(rows, models.FileTag{
+				ID:        uuid.New().String(),
+				FileID:    fileID,
+				UserID:    userID,
+				Tag:       tag,
+				CreatedAt: now,
+			})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// GetFileTags retrieves the tags of a file in alphabetical order
+//
+// Parameters:
+//   - fileID: The ID of the file
+//
+// Returns:
+//   - A slice of tags
+//   - An error if the database operation fails
+func GetFileTags(fileID string) ([]string, error) {
+	tags := []string{}
+	err := db.DB.Model(&models.FileTag{}).Where("file_id = ?", fileID).Order("tag ASC").Pluck("tag", &tags).Error
+	return tags, err
+}
+
+// SetFileMetadata replaces the key/value metadata of a file with the given map
+//
+// Parameters:
+//   - fileID: The ID of the file
+//   - userID: The ID of the user who owns the file
+//   - metadata: The complete set of key/value pairs the file should have
+//
+// Returns:
+//   - An error if the database operation fails
+func SetFileMetadata(fileID string, userID string, metadata map[string]string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("file_id = ?", fileID).Delete(&models.FileMetadata{}).Error; err != nil {
+			return err
+		}
+		if len(metadata) == 0 {
+			return nil
+		}
+
+		keys := make([]string, 0, len(metadata))
+		for key := range metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		now := time.Now()
+		rows := make([]models.FileMetadata, 0, len(metadata))
+		for _, key := range keys {
+			rows = append(rows, models.FileMetadata{
+				ID:        uuid.New().String(),
+				FileID:    fileID,
+				UserID:    userID,
+				Key:       key,
+				Value:     metadata[key],
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// GetFileMetadata retrieves the key/value metadata of a file
+//
+// Parameters:
+//   - fileID: The ID of the file
+//
+// Returns:
+//   - A map of metadata keys to values
+//   - An error if the database operation fails
+func GetFileMetadata(fileID string) (map[string]string, error) {
+	var rows []models.FileMetadata
+	if err := db.DB.Where("file_id = ?", fileID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]string, len(rows))
+	for _, row := range rows {
+		metadata[row.Key] = row.Value
+	}
+	return metadata, nil
+}
+
+// FindFilesByAttributes retrieves a user's live files that carry every given tag and
+// every given metadata key/value pair, most recently created first
+//
+// Parameters:
+//   - userID: The ID of the user who owns the files
+//   - tags: Tags the files must all have
+//   - metadata: Key/value pairs the files must all have
+//   - limit: The maximum number of files to return
+//   - offset: The number of matching files to skip
+//
+// Returns:
+//   - A slice of File models
+//   - An error if the database operation fails
+func FindFilesByAttributes(userID string, tags []string, metadata map[string]string, limit int, offset int) ([]models.File, error) {
+	query := db.DB.Where("user_id = ?", userID)
+	if len(tags) > 0 {
+		query = query.Where("id IN (?)", taggedFileIDs(db.DB, tags))
+	}
+	for key, value := range metadata {
+		query = query.Where("id IN (?)", db.DB.Model(&models.FileMetadata{}).Select("file_id").Where("key = ? AND value = ?", key, value))
+	}
+
+	var files []models.File
+	err := query.Order("created_at DESC, id ASC").Limit(limit).Offset(offset).Find(&files).Error
+	return files, err
+}
+
+// taggedFileIDs builds a subquery selecting the IDs of the files that carry every given tag
+func taggedFileIDs(tx *gorm.DB, tags []string) *gorm.DB {
+	return tx.Model(&models.FileTag{}).
+		Select("file_id").
+		Where("tag IN ?", tags).
+		Group("file_id").
+		Having("COUNT(*) = ?", len(tags))
+}
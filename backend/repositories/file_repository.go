@@ -2,13 +2,19 @@
 package repositories
 
 import (
+	"errors"
 	"time"
 
 	"github.com/AnshJain-Shwalia/DataHub/backend/db"
 	"github.com/AnshJain-Shwalia/DataHub/backend/models"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ErrEncryptionMismatch is returned when a version would be added to a file whose end-to-end
+// encryption does not match the content of the version
+var ErrEncryptionMismatch = errors.New("content encryption does not match the file")
+
 // CreateFile creates a new file in the database
 // It takes file details as parameters and returns the created file
 //
@@ -34,4 +40,450 @@ func CreateFile(name string, size int64, userID string, folderID *string) (*mode
 
 	// Create record in database and return any errors
 	return file, db.DB.Create(file).Error
-}
\ No newline at end of file
+}
+
+// FindFileByIDForUser retrieves a file by its ID, scoped to the owning user
+// Files owned by other users are reported as not found
+//
+// Parameters:
+//   - fileID: The ID of the file to retrieve
+//   - userID: The ID of the user who must own the file
+//
+// Returns:
+//   - A pointer to the File model if found
+//   - gorm.ErrRecordNotFound if the file does not exist or belongs to another user
+func FindFileByIDForUser(fileID string, userID string) (*models.File, error) {
+	var file models.File
+	err := db.DB.Where("id = ? AND user_id = ?", fileID, userID).First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// MoveFile moves a file into another folder and/or renames it inside a single transaction
+// The destination folder must belong to the same user as the file
+//
+// Parameters:
+//   - fileID: The ID of the file to move
+//   - userID: The ID of the user who owns the file
+//   - destinationFolderID: The ID of the destination folder, or nil for the user's root
+//   - name: The name the file should have after the move
+//   - conflictPolicy: How to handle a name already taken in the destination (fail, rename or overwrite)
+//
+// Returns:
+//   - A pointer to the updated File model
+//   - ErrDestinationNotFound if the destination folder is missing or owned by another user
+//   - ErrNameConflict if the name is taken and the policy does not resolve it
+//   - gorm.ErrRecordNotFound if the file does not exist or belongs to another user
+func MoveFile(fileID string, userID string, destinationFolderID *string, name string, conflictPolicy string) (*models.File, error) {
+	return moveFile(fileID, userID, destinationFolderID, false, name, conflictPolicy)
+}
+
+// RenameFile renames a file inside a single transaction, leaving it in its current folder.
+// The folder is read under the tree lock, so a concurrent move is never undone by the rename.
+//
+// Parameters:
+//   - fileID: The ID of the file to rename
+//   - userID: The ID of the user who owns the file
+//   - name: The new name of the file
+//   - conflictPolicy: How to handle a name already taken in the folder (fail, rename or overwrite)
+//
+// Returns:
+//   - A pointer to the updated File model
+//   - ErrNameConflict if the name is taken and the policy does not resolve it
+//   - gorm.ErrRecordNotFound if the file does not exist or belongs to another user
+func RenameFile(fileID string, userID string, name string, conflictPolicy string) (*models.File, error) {
+	return moveFile(fileID, userID, nil, true, name, conflictPolicy)
+}
+
+// moveFile applies a move and/or rename of a file. With keepFolder the file stays in the folder
+// it is in when the transaction reads it, and destinationFolderID is ignored.
+func moveFile(fileID string, userID string, destinationFolderID *string, keepFolder bool, name string, conflictPolicy string) (*models.File, error) {
+	var file models.File
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialise tree changes per user so concurrent moves can't interleave
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND user_id = ?", fileID, userID).First(&file).Error; err != nil {
+			return err
+		}
+
+		if keepFolder {
+			destinationFolderID = file.FolderID
+		} else if destinationFolderID != nil {
+			if err := ensureFolderOwned(tx, *destinationFolderID, userID); err != nil {
+				return err
+			}
+		}
+
+		resolution, err := resolveNameConflict(tx, userID, destinationFolderID, name, conflictPolicy, true, fileID)
+		if err != nil {
+			return err
+		}
+		if resolution.replaceFileID != nil {
+			if err := trashFileByID(tx, *resolution.replaceFileID); err != nil {
+				return err
+			}
+		}
+
+		file.FolderID = destinationFolderID
+		file.Name = resolution.name
+		return tx.Model(&file).Updates(map[string]interface{}{
+			"folder_id": destinationFolderID,
+			"name":      resolution.name,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// CreateFileWithChunks starts an upload: it creates a file version that is still being uploaded
+// together with its chunk records in a single transaction. Each chunk is given a buffer key via
+// the chunkKey callback, unless its hash and size match a chunk the user already stores: such a
+// chunk takes over the status and location of the stored one, so it is not uploaded again. When
+// the hash of the whole file matches a completed version the user already stores, that version's
+// chunks are reused instead and the new version is complete immediately. The client's hashes are
+// only used for these lookups; the server hashes the stored content once the version is complete.
+// Deduplication is scoped to the user, so a client can only ever reference its own data by hash.
+// It is skipped while one of the user's storage accounts is being emptied.
+// A new file is created for the version unless the name is taken by a file and the overwrite
+// policy is used, in which case the upload becomes the next version of that file and the
+// existing versions are kept.
+//
+// Parameters:
+//   - name: The name of the file
+//   - size: The size of the file in bytes
+//   - fileHash: The hex SHA-256 of the whole file, or nil if the client did not hash it
+//   - userID: The ID of the user who owns this file
+//   - folderID: Optional pointer to the parent folder ID (can be nil for root files)
+//   - chunkSizes: The size of every chunk in rank order
+//   - chunkHashes: The hex SHA-256 of every chunk in rank order, or nil if the client did not hash them
+//   - chunkKey: Builds the S3 buffer key of a chunk from the file and chunk IDs
+//   - conflictPolicy: How to handle a name already taken in the folder (fail, rename or overwrite)
+//   - clientWrappedKey: For end-to-end encrypted content, the file's data key wrapped by the user's
+//     key; nil for plaintext content. A version of an existing file must use that file's key.
+//
+// Returns:
+//   - A pointer to the new or existing File model
+//   - A pointer to the created FileVersion model with its Chunks populated; its status is
+//     COMPLETE when the file's content was already stored
+//   - The blobs of older pending uploads of an existing file, which are aborted by the new upload;
+//     the caller removes them from S3 and GitHub
+//   - ErrNameConflict if the name is taken and the policy does not resolve it
+//   - ErrEncryptionMismatch if a version is added to a file that is encrypted differently or with
+//     a different wrapped key
+//   - An error if the database operation fails
+func CreateFileWithChunks(
+	name string,
+	size int64,
+	fileHash *string,
+	userID string,
+	folderID *string,
+	chunkSizes []int64,
+	chunkHashes []string,
+	chunkKey func(fileID, chunkID string) string,
+	conflictPolicy string,
+	clientWrappedKey *string) (*models.File, *models.FileVersion, *ReleasedBlobs, error) {
+	now := time.Now()
+	var file *models.File
+	var version *models.FileVersion
+	aborted := &ReleasedBlobs{}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+		resolution, err := resolveNameConflict(tx, userID, folderID, name, conflictPolicy, true, "")
+		if err != nil {
+			return err
+		}
+
+		// Re-uploading onto an existing file adds a version instead of replacing the file
+		var number int
+		if file, number, err = versionTarget(tx, resolution, userID, folderID, size, clientWrappedKey, now); err != nil {
+			return err
+		}
+		if resolution.replaceFileID != nil {
+			if aborted, err = abortPendingVersions(tx, file.ID); err != nil {
+				return err
+			}
+		}
+
+		// Stored chunks may be moving between storage accounts, so they are not reused until it is done
+		unlocked, err := tryLockStorageAccountsShared(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !unlocked {
+			fileHash, chunkHashes = nil, nil
+		}
+
+		if fileHash != nil {
+			source, sourceChunks, err := findCompleteVersionByHash(tx, userID, *fileHash, size)
+			if err == nil {
+				version, err = createVersionCopy(tx, file, number, source, sourceChunks, now)
+				return err
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		version = &models.FileVersion{
+			ID:        uuid.New().String(),
+			FileID:    file.ID,
+			Number:    number,
+			Size:      size,
+			Status:    models.FileStatusUploading,
+			CreatedAt: now,
+		}
+		stored, err := findStoredChunksByHash(tx, userID, chunkHashes)
+		if err != nil {
+			return err
+		}
+		for rank, chunkSize := range chunkSizes {
+			chunk := models.Chunk{
+				ID:        uuid.New().String(),
+				FileID:    file.ID,
+				VersionID: &version.ID,
+				Rank:      rank,
+				Size:      chunkSize,
+				Status:    models.ChunkStatusPending,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			var existing *models.Chunk
+			if chunkHashes != nil {
+				existing = stored[chunkContent{hash: chunkHashes[rank], size: chunkSize}]
+			}
+			if existing != nil {
+				chunk.Hash = existing.Hash
+				chunk.S3Path = existing.S3Path
+				chunk.BranchID = existing.BranchID
+				chunk.GitPath = existing.GitPath
+				chunk.Status = existing.Status
+			} else {
+				key := chunkKey(file.ID, chunk.ID)
+				chunk.S3Path = &key
+			}
+			version.Chunks = append(version.Chunks, chunk)
+		}
+
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		if len(version.Chunks) == 0 {
+			return nil
+		}
+		return tx.Create(&version.Chunks).Error
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return file, version, aborted, nil
+}
+
+// CopyFile copies the current version of a file into a folder without duplicating its content:
+// the new version gets its own chunk records that reference the same stored blobs as the source.
+// Like an upload, copying onto a file with the overwrite policy adds a version to that file.
+//
+// Parameters:
+//   - fileID: The ID of the file to copy
+//   - userID: The ID of the user who owns the file
+//   - destinationFolderID: The ID of the destination folder, or nil for the user's root
+//   - name: The name the copy should have
+//   - conflictPolicy: How to handle a name already taken in the destination (fail, rename or overwrite)
+//
+// Returns:
+//   - A pointer to the new or overwritten File model
+//   - A pointer to the created FileVersion model with its Chunks populated
+//   - ErrVersionIncomplete if the file has no completed version to copy
+//   - ErrDestinationNotFound if the destination folder is missing or owned by another user
+//   - ErrNameConflict if the name is taken and the policy does not resolve it
+//   - ErrEncryptionMismatch if the copy would overwrite a file that is encrypted differently
+//   - gorm.ErrRecordNotFound if the file does not exist or belongs to another user
+func CopyFile(fileID string, userID string, destinationFolderID *string, name string, conflictPolicy string) (*models.File, *models.FileVersion, error) {
+	now := time.Now()
+	var file *models.File
+	var version *models.FileVersion
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// Holding the tree lock also keeps the source from being trashed and purged while it is copied
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+
+		var source models.File
+		if err := tx.Where("id = ? AND user_id = ?", fileID, userID).First(&source).Error; err != nil {
+			return err
+		}
+		if source.CurrentVersionID == nil {
+			return ErrVersionIncomplete
+		}
+		var sourceVersion models.FileVersion
+		if err := tx.Where("id = ?", *source.CurrentVersionID).First(&sourceVersion).Error; err != nil {
+			return err
+		}
+		var sourceChunks []models.Chunk
+		if err := tx.Where("version_id = ?", sourceVersion.ID).Order("rank ASC").Find(&sourceChunks).Error; err != nil {
+			return err
+		}
+
+		if destinationFolderID != nil {
+			if err := ensureFolderOwned(tx, *destinationFolderID, userID); err != nil {
+				return err
+			}
+		}
+		resolution, err := resolveNameConflict(tx, userID, destinationFolderID, name, conflictPolicy, true, "")
+		if err != nil {
+			return err
+		}
+		// A file can't be overwritten with a copy of itself
+		if resolution.replaceFileID != nil && *resolution.replaceFileID == source.ID {
+			return ErrNameConflict
+		}
+
+		var sourceKey *string
+		if source.ClientEncrypted {
+			sourceKey = source.ClientWrappedKey
+		}
+		var number int
+		if file, number, err = versionTarget(tx, resolution, userID, destinationFolderID, sourceVersion.Size, sourceKey, now); err != nil {
+			return err
+		}
+		// Encrypted chunks can only be read with the key of the file they were copied from
+		if source.ClientEncrypted && *file.ClientWrappedKey != *source.ClientWrappedKey {
+			return ErrEncryptionMismatch
+		}
+		version, err = createVersionCopy(tx, file, number, &sourceVersion, sourceChunks, now)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, version, nil
+}
+
+// versionTarget returns the file a new version is added to and the number the version gets: the
+// file replaced under the overwrite policy, or otherwise a newly created file that is still uploading.
+// A non-nil clientWrappedKey marks the content as end-to-end encrypted.
+func versionTarget(tx *gorm.DB, resolution *nameResolution, userID string, folderID *string, size int64, clientWrappedKey *string, now time.Time) (*models.File, int, error) {
+	file := &models.File{}
+	if resolution.replaceFileID != nil {
+		if err := tx.Where("id = ?", *resolution.replaceFileID).First(file).Error; err != nil {
+			return nil, 0, err
+		}
+		if file.ClientEncrypted != (clientWrappedKey != nil) {
+			return nil, 0, ErrEncryptionMismatch
+		}
+		// Every version of an encrypted file is sealed with the file's single data key
+		if clientWrappedKey != nil && (file.ClientWrappedKey == nil || *clientWrappedKey != *file.ClientWrappedKey) {
+			return nil, 0, ErrEncryptionMismatch
+		}
+		number, err := nextVersionNumber(tx, file.ID)
+		return file, number, err
+	}
+
+	file = &models.File{
+		ID:               uuid.New().String(),
+		Name:             resolution.name,
+		Size:             size,
+		Status:           models.FileStatusUploading,
+		UserID:           userID,
+		FolderID:         folderID,
+		ClientEncrypted:  clientWrappedKey != nil,
+		ClientWrappedKey: clientWrappedKey,
+		CreatedAt:        now,
+	}
+	return file, 1, tx.Create(file).Error
+}
+
+// findCompleteVersionByHash looks up a completed version of one of the user's live files with the
+// given content hash and size, together with its chunks in rank order. Version hashes are only set
+// by the server from the stored content. Versions of trashed files are skipped, since the purge
+// worker is about to release their blobs.
+func findCompleteVersionByHash(tx *gorm.DB, userID string, hash string, size int64) (*models.FileVersion, []models.Chunk, error) {
+	var version models.FileVersion
+	err := tx.Joins("JOIN files ON files.id = file_versions.file_id").
+		Where("files.user_id = ? AND files.deleted_at IS NULL AND file_versions.hash = ? AND file_versions.size = ? AND file_versions.status = ?",
+			userID, hash, size, models.FileStatusComplete).
+		Order("file_versions.created_at ASC").
+		First(&version).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	var chunks []models.Chunk
+	err = tx.Where("version_id = ?", version.ID).Order("rank ASC").Find(&chunks).Error
+	return &version, chunks, err
+}
+
+// createVersionCopy adds a completed version to a file whose chunk records reference the stored
+// blobs of an existing version, and makes it the file's current version
+func createVersionCopy(tx *gorm.DB, file *models.File, number int, source *models.FileVersion, sourceChunks []models.Chunk, now time.Time) (*models.FileVersion, error) {
+	version := &models.FileVersion{
+		ID:          uuid.New().String(),
+		FileID:      file.ID,
+		Number:      number,
+		Size:        source.Size,
+		Hash:        source.Hash,
+		Status:      models.FileStatusComplete,
+		ContentType: source.ContentType,
+		CreatedAt:   now,
+	}
+	for _, chunk := range sourceChunks {
+		version.Chunks = append(version.Chunks, models.Chunk{
+			ID:        uuid.New().String(),
+			FileID:    file.ID,
+			VersionID: &version.ID,
+			Rank:      chunk.Rank,
+			Size:      chunk.Size,
+			Hash:      chunk.Hash,
+			S3Path:    chunk.S3Path,
+			GitPath:   chunk.GitPath,
+			BranchID:  chunk.BranchID,
+			Status:    chunk.Status,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	if err := tx.Create(version).Error; err != nil {
+		return nil, err
+	}
+	if len(version.Chunks) > 0 {
+		if err := tx.Create(&version.Chunks).Error; err != nil {
+			return nil, err
+		}
+	}
+	return version, setCurrentVersion(tx, file, version)
+}
+
+// FindFileByName retrieves the file with the given name directly inside a folder
+//
+// Parameters:
+//   - userID: The ID of the user who owns the file
+//   - folderID: The ID of the containing folder, or nil for the user's root
+//   - name: The name of the file to find
+//
+// Returns:
+//   - A pointer to the File model if found
+//   - gorm.ErrRecordNotFound if no such file exists
+func FindFileByName(userID string, folderID *string, name string) (*models.File, error) {
+	query := db.DB.Where("user_id = ? AND name = ?", userID, name)
+	if folderID == nil {
+		query = query.Where("folder_id IS NULL")
+	} else {
+		query = query.Where("folder_id = ?", *folderID)
+	}
+
+	var file models.File
+	if err := query.First(&file).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
@@ -0,0 +1,311 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionIncomplete is returned when a version that is still being uploaded is used as content
+var ErrVersionIncomplete = errors.New("file version upload has not completed")
+
+// ListFileVersions retrieves every version of a file, newest first
+//
+// Parameters:
+//   - fileID: The ID of the file whose versions should be returned
+//
+// Returns:
+//   - A slice of FileVersion models ordered by descending version number
+//   - An error if the database operation fails
+func ListFileVersions(fileID string) ([]models.FileVersion, error) {
+	var versions []models.FileVersion
+	err := db.DB.Where("file_id = ?", fileID).Order("number DESC").Find(&versions).Error
+	return versions, err
+}
+
+// FindFileVersion retrieves a single version of a file
+//
+// Parameters:
+//   - fileID: The ID of the file the version belongs to
+//   - versionID: The ID of the version to retrieve
+//
+// Returns:
+//   - A pointer to the FileVersion model if found
+//   - gorm.ErrRecordNotFound if the version does not exist or belongs to another file
+func FindFileVersion(fileID string, versionID string) (*models.FileVersion, error) {
+	var version models.FileVersion
+	err := db.DB.Where("id = ? AND file_id = ?", versionID, fileID).First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// FindLatestUploadingVersion retrieves the newest version of a file whose upload has not been finalized
+//
+// Parameters:
+//   - fileID: The ID of the file
+//
+// Returns:
+//   - A pointer to the FileVersion model if found
+//   - gorm.ErrRecordNotFound if the file has no pending upload
+func FindLatestUploadingVersion(fileID string) (*models.FileVersion, error) {
+	var version models.FileVersion
+	err := db.DB.Where("file_id = ? AND status = ?", fileID, models.FileStatusUploading).
+		Order("number DESC").
+		First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// CompleteFileVersion marks a version as fully uploaded and makes it the file's current version
+//
+// Parameters:
+//   - file: The file the version belongs to; its fields are updated in place
+//   - version: The version whose upload has completed; its status is updated in place
+//   - contentType: The content type detected from the uploaded data, or nil if unknown
+//
+// Returns:
+//   - gorm.ErrRecordNotFound if the version is no longer pending, e.g. because a newer upload aborted it
+//   - An error if the database operation fails
+func CompleteFileVersion(file *models.File, version *models.FileVersion, contentType *string) error {
+	// The file mirrors the content type of its current version, so it is set before the version is made current
+	version.ContentType = contentType
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FileVersion{}).Where("id = ? AND status = ?", version.ID, models.FileStatusUploading).Updates(map[string]interface{}{
+			"status":       models.FileStatusComplete,
+			"content_type": contentType,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return setCurrentVersion(tx, file, version)
+	})
+	if err != nil {
+		return err
+	}
+	version.Status = models.FileStatusComplete
+	return nil
+}
+
+// FindVersionsToHash retrieves completed versions of live files whose content the server has not
+// hashed yet, oldest first. Versions of end-to-end encrypted files are never hashed.
+//
+// Parameters:
+//   - skipIDs: IDs of versions to leave out, e.g. because hashing them failed
+//   - limit: The maximum number of versions to return
+//
+// Returns:
+//   - A slice of FileVersion models
+//   - An error if the database operation fails
+func FindVersionsToHash(skipIDs []string, limit int) ([]models.FileVersion, error) {
+	query := db.DB.Joins("JOIN files ON files.id = file_versions.file_id").
+		Where("file_versions.status = ? AND file_versions.hash IS NULL AND files.deleted_at IS NULL AND NOT files.client_encrypted",
+			models.FileStatusComplete)
+	if len(skipIDs) > 0 {
+		query = query.Where("file_versions.id NOT IN ?", skipIDs)
+	}
+
+	var versions []models.FileVersion
+	err := query.Order("file_versions.created_at ASC").Limit(limit).Find(&versions).Error
+	return versions, err
+}
+
+// SaveContentHashes records the hashes the server computed from the stored content of a version.
+// Hashes that are already set are left unchanged.
+//
+// Parameters:
+//   - versionID: The ID of the hashed version
+//   - versionHash: The hex SHA-256 of the version's whole content
+//   - chunkHashes: The hex SHA-256 of every chunk of the version, keyed by chunk ID
+//
+// Returns:
+//   - An error if the database operation fails
+func SaveContentHashes(versionID string, versionHash string, chunkHashes map[string]string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		for chunkID, hash := range chunkHashes {
+			if err := tx.Model(&models.Chunk{}).
+				Where("id = ? AND hash IS NULL", chunkID).
+				Update("hash", hash).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.FileVersion{}).
+			Where("id = ? AND hash IS NULL", versionID).
+			Update("hash", versionHash).Error
+	})
+}
+
+// SetVersionThumbnail records where the thumbnail of a file version is stored
+//
+// Parameters:
+//   - versionID: The ID of the file version
+//   - thumbnailKey: The S3 key of the thumbnail
+//
+// Returns:
+//   - An error if the database operation fails
+func SetVersionThumbnail(versionID string, thumbnailKey string) error {
+	return db.DB.Model(&models.FileVersion{}).Where("id = ?", versionID).Update("thumbnail_key", thumbnailKey).Error
+}
+
+// SetCurrentVersion makes a completed version the one served when the file is downloaded
+//
+// Parameters:
+//   - file: The file the version belongs to; its fields are updated in place
+//   - version: The version to promote
+//
+// Returns:
+//   - ErrVersionIncomplete if the version has not finished uploading
+//   - gorm.ErrRecordNotFound if the version has been pruned in the meantime
+//   - An error if the database operation fails
+func SetCurrentVersion(file *models.File, version *models.FileVersion) error {
+	if version.Status != models.FileStatusComplete {
+		return ErrVersionIncomplete
+	}
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		// Pruning holds the same lock, so the version is either still there or already gone
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", file.ID).First(&models.File{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("id").Where("id = ? AND file_id = ?", version.ID, file.ID).First(&models.FileVersion{}).Error; err != nil {
+			return err
+		}
+		return setCurrentVersion(tx, file, version)
+	})
+}
+
+// PruneFileVersions permanently deletes the completed versions of a file that fall outside a
+// retention rule, together with their chunks, and releases the storage their pushed chunks occupied.
+// A version is pruned when it is not among the keepLatest newest completed versions, or when it was
+// created before the cutoff. The current version is never pruned.
+// The versions are chosen and deleted in one transaction that holds the user's tree lock and a lock
+// on the file, so neither a promotion nor a copy or deduplicated upload can start using them in between.
+// The blobs that are no longer referenced are returned; the caller removes them from GitHub and S3
+// once this has returned, so no stored content disappears while a chunk still points at it.
+//
+// Parameters:
+//   - fileID: The ID of the file whose versions are pruned
+//   - userID: The ID of the user who owns the file
+//   - keepLatest: The number of newest completed versions to keep, or nil to ignore the count
+//   - cutoff: Versions created before this time are pruned, or nil to ignore the age
+//
+// Returns:
+//   - The IDs of the pruned versions, oldest first
+//   - The blobs and thumbnails that are no longer referenced
+//   - gorm.ErrRecordNotFound if the file does not exist or belongs to another user
+//   - An error if the database operation fails
+func PruneFileVersions(fileID string, userID string, keepLatest *int, cutoff *time.Time) ([]string, *ReleasedBlobs, error) {
+	var pruned []string
+	var released *ReleasedBlobs
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+		// Lock the file so a concurrent promotion can't make one of the versions current
+		var file models.File
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND user_id = ?", fileID, userID).First(&file).Error; err != nil {
+			return err
+		}
+
+		var completed []models.FileVersion
+		if err := tx.Where("file_id = ? AND status = ?", file.ID, models.FileStatusComplete).
+			Order("number DESC").
+			Find(&completed).Error; err != nil {
+			return err
+		}
+		var thumbnails []string
+		for i := len(completed) - 1; i >= 0; i-- {
+			version := completed[i]
+			if file.CurrentVersionID != nil && version.ID == *file.CurrentVersionID {
+				continue
+			}
+			if (keepLatest != nil && i >= *keepLatest) || (cutoff != nil && version.CreatedAt.Before(*cutoff)) {
+				pruned = append(pruned, version.ID)
+				if version.ThumbnailKey != nil {
+					thumbnails = append(thumbnails, *version.ThumbnailKey)
+				}
+			}
+		}
+		if len(pruned) == 0 {
+			released = &ReleasedBlobs{}
+			return nil
+		}
+
+		chunkIDs := tx.Model(&models.Chunk{}).Select("id").Where("version_id IN ?", pruned)
+		var err error
+		if released, err = findReleasedBlobs(tx, chunkIDs); err != nil {
+			return err
+		}
+		released.Thumbnails = thumbnails
+		if err := deleteChunks(tx, chunkIDs); err != nil {
+			return err
+		}
+		return tx.Where("file_id = ? AND id IN ?", file.ID, pruned).Delete(&models.FileVersion{}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return pruned, released, nil
+}
+
+// abortPendingVersions deletes the versions of a file whose upload has not been finalized, together
+// with their chunks, inside a transaction that holds the owner's tree lock. Only the newest pending
+// version can ever be finalized, so older ones are aborted as soon as a newer upload starts.
+// The blobs that are no longer referenced are returned for the caller to remove after the commit.
+func abortPendingVersions(tx *gorm.DB, fileID string) (*ReleasedBlobs, error) {
+	// Locking the rows keeps a finalize that is running at the same time from completing one of them
+	var pending []string
+	if err := tx.Model(&models.FileVersion{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("file_id = ? AND status = ?", fileID, models.FileStatusUploading).
+		Pluck("id", &pending).Error; err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return &ReleasedBlobs{}, nil
+	}
+
+	chunkIDs := tx.Model(&models.Chunk{}).Select("id").Where("version_id IN ?", pending)
+	released, err := findReleasedBlobs(tx, chunkIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := deleteChunks(tx, chunkIDs); err != nil {
+		return nil, err
+	}
+	return released, tx.Where("id IN ?", pending).Delete(&models.FileVersion{}).Error
+}
+
+// nextVersionNumber returns the number the next version of a file should get
+func nextVersionNumber(tx *gorm.DB, fileID string) (int, error) {
+	var latest int
+	err := tx.Model(&models.FileVersion{}).Select("COALESCE(MAX(number), 0)").Where("file_id = ?", fileID).Scan(&latest).Error
+	return latest + 1, err
+}
+
+// setCurrentVersion points a file at a version and mirrors the version's size and content type onto the file
+func setCurrentVersion(tx *gorm.DB, file *models.File, version *models.FileVersion) error {
+	err := tx.Model(&models.File{}).Where("id = ?", file.ID).Updates(map[string]interface{}{
+		"current_version_id": version.ID,
+		"size":               version.Size,
+		"content_type":       version.ContentType,
+		"status":             models.FileStatusComplete,
+	}).Error
+	if err != nil {
+		return err
+	}
+	file.CurrentVersionID = &version.ID
+	file.Size = version.Size
+	file.ContentType = version.ContentType
+	file.Status = models.FileStatusComplete
+	return nil
+}
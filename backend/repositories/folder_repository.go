@@ -0,0 +1,417 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateFolder creates a new folder in the database
+// The folder name must not already be used by a folder or file in the same parent
+//
+// Parameters:
+//   - name: The name of the folder
+//   - userID: The ID of the user who owns this folder
+//   - parentFolderID: Optional pointer to the parent folder ID (nil for root folders)
+//
+// Returns:
+//   - A pointer to the created Folder model (with ID and timestamps populated)
+//   - ErrNameConflict if the parent already contains an item with the same name
+//   - An error if the database operation fails
+func CreateFolder(name string, userID string, parentFolderID *string) (*models.Folder, error) {
+	// Create folder struct with provided data and current timestamp
+	folder := &models.Folder{
+		ID:             uuid.New().String(),
+		Name:           name,
+		ParentFolderID: parentFolderID,
+		UserID:         userID,
+		CreatedAt:      time.Now(),
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+		if _, err := resolveNameConflict(tx, userID, parentFolderID, name, ConflictPolicyFail, false, ""); err != nil {
+			return err
+		}
+		return tx.Create(folder).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// FindFolderByIDForUser retrieves a folder by its ID, scoped to the owning user
+// Folders owned by other users are reported as not found
+//
+// Parameters:
+//   - folderID: The ID of the folder to retrieve
+//   - userID: The ID of the user who must own the folder
+//
+// Returns:
+//   - A pointer to the Folder model if found
+//   - gorm.ErrRecordNotFound if the folder does not exist or belongs to another user
+func FindFolderByIDForUser(folderID string, userID string) (*models.Folder, error) {
+	var folder models.Folder
+	err := db.DB.Where("id = ? AND user_id = ?", folderID, userID).First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// Directory entry kinds returned by ListFolderChildren. Folders sort before files.
+const (
+	EntryKindFolder = 0
+	EntryKindFile   = 1
+)
+
+// DirectoryEntry is a single folder or file row in a directory listing
+type DirectoryEntry struct {
+	Kind        int
+	ID          string
+	Name        string
+	Size        int64
+	ContentType *string // files only
+	CreatedAt   time.Time
+}
+
+// DirectoryCursor identifies the last entry of a previous page in a directory listing.
+// SortValue holds the value of the sort column for that entry (string, int64 or time.Time).
+type DirectoryCursor struct {
+	Kind      int
+	SortValue interface{}
+	ID        string
+}
+
+// directorySortColumns whitelists the columns a directory listing can be ordered by
+var directorySortColumns = map[string]string{
+	"name":       "name",
+	"size":       "size",
+	"created_at": "created_at",
+}
+
+// ListFolderChildren returns one page of the subfolders and files directly inside a folder.
+// Folders are always listed before files; within each kind entries are ordered by the sort
+// column and then by ID so that keyset pagination is stable.
+//
+// Parameters:
+//   - userID: The ID of the user who owns the folder tree
+//   - folderID: The ID of the folder to list, or nil for the user's root
+//   - sortBy: The column to order by ("name", "size" or "created_at")
+//   - descending: Whether to order the sort column in descending order
+//   - after: Optional cursor of the last entry of the previous page (nil for the first page)
+//   - limit: The maximum number of entries to return
+//   - tags: Optional tags every listed file must carry; folders are omitted when tags are given
+//
+// Returns:
+//   - The entries of the requested page
+//   - An error if the sort column is unknown or the database operation fails
+func ListFolderChildren(userID string, folderID *string, sortBy string, descending bool, after *DirectoryCursor, limit int, tags []string) ([]DirectoryEntry, error) {
+	column, ok := directorySortColumns[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort column %q", sortBy)
+	}
+
+	// Both branches of the union hit the folder_id/parent_folder_id indexes,
+	// or the user_id index when listing the root
+	// Soft-deleted (trashed) rows are excluded explicitly since raw SQL bypasses GORM's scope
+	folderFilter, fileFilter := "parent_folder_id IS NULL", "folder_id IS NULL"
+	args := []interface{}{userID, userID}
+	if folderID != nil {
+		folderFilter, fileFilter = "parent_folder_id = ?", "folder_id = ?"
+		args = []interface{}{userID, *folderID, userID, *folderID}
+	}
+	// Folders carry no tags, so a tag filter leaves only matching files
+	if len(tags) > 0 {
+		folderFilter = "FALSE"
+		fileFilter += " AND id IN (?)"
+		args = []interface{}{userID, userID}
+		if folderID != nil {
+			args = append(args, *folderID)
+		}
+		args = append(args, taggedFileIDs(db.DB, tags))
+	}
+
+	query := fmt.Sprintf(`SELECT kind, id, name, size, content_type, created_at FROM (
+		SELECT %d AS kind, id, name, 0::bigint AS size, NULL::varchar AS content_type, created_at FROM folders WHERE user_id = ? AND %s AND deleted_at IS NULL
+		UNION ALL
+		SELECT %d AS kind, id, name, size, content_type, created_at FROM files WHERE user_id = ? AND %s AND deleted_at IS NULL
+	) entries`, EntryKindFolder, folderFilter, EntryKindFile, fileFilter)
+
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if after != nil {
+		query += fmt.Sprintf(" WHERE kind > ? OR (kind = ? AND (%s, id) %s (?, ?))", column, comparison)
+		args = append(args, after.Kind, after.Kind, after.SortValue, after.ID)
+	}
+	query += fmt.Sprintf(" ORDER BY kind ASC, %s %s, id %s LIMIT ?", column, direction, direction)
+	args = append(args, limit)
+
+	var entries []DirectoryEntry
+	err := db.DB.Raw(query, args...).Scan(&entries).Error
+	return entries, err
+}
+
+var (
+	// ErrDestinationNotFound is returned when a move targets a folder that is missing or owned by another user
+	ErrDestinationNotFound = errors.New("destination folder not found")
+	// ErrMoveIntoDescendant is returned when a folder would be moved into itself or one of its descendants
+	ErrMoveIntoDescendant = errors.New("cannot move a folder into itself or one of its descendants")
+)
+
+// MoveFolder moves a folder under another parent and/or renames it inside a single transaction
+// The move is rejected if the destination is the folder itself or one of its descendants,
+// or if the destination belongs to another user
+//
+// Parameters:
+//   - folderID: The ID of the folder to move
+//   - userID: The ID of the user who owns the folder
+//   - destinationParentID: The ID of the new parent folder, or nil to move to the user's root
+//   - name: The name the folder should have after the move
+//   - conflictPolicy: How to handle a name already taken in the destination (fail or rename)
+//
+// Returns:
+//   - A pointer to the updated Folder model
+//   - ErrDestinationNotFound, ErrMoveIntoDescendant or ErrNameConflict if the move is not allowed
+//   - gorm.ErrRecordNotFound if the folder does not exist or belongs to another user
+func MoveFolder(folderID string, userID string, destinationParentID *string, name string, conflictPolicy string) (*models.Folder, error) {
+	return moveFolder(folderID, userID, destinationParentID, false, name, conflictPolicy)
+}
+
+// RenameFolder renames a folder inside a single transaction, leaving it under its current parent.
+// The parent is read under the tree lock, so a concurrent move is never undone by the rename.
+//
+// Parameters:
+//   - folderID: The ID of the folder to rename
+//   - userID: The ID of the user who owns the folder
+//   - name: The new name of the folder
+//   - conflictPolicy: How to handle a name already taken in the parent (fail or rename)
+//
+// Returns:
+//   - A pointer to the updated Folder model
+//   - ErrNameConflict if the name is taken and the policy does not resolve it
+//   - gorm.ErrRecordNotFound if the folder does not exist or belongs to another user
+func RenameFolder(folderID string, userID string, name string, conflictPolicy string) (*models.Folder, error) {
+	return moveFolder(folderID, userID, nil, true, name, conflictPolicy)
+}
+
+// moveFolder applies a move and/or rename of a folder. With keepParent the folder stays under the
+// parent it has when the transaction reads it, and destinationParentID is ignored.
+func moveFolder(folderID string, userID string, destinationParentID *string, keepParent bool, name string, conflictPolicy string) (*models.Folder, error) {
+	var folder models.Folder
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialise tree changes per user so two concurrent moves can't form a cycle together
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND user_id = ?", folderID, userID).First(&folder).Error; err != nil {
+			return err
+		}
+
+		if keepParent {
+			destinationParentID = folder.ParentFolderID
+		} else if destinationParentID != nil {
+			if err := ensureFolderOwned(tx, *destinationParentID, userID); err != nil {
+				return err
+			}
+			isDescendant, err := isSameOrDescendantFolder(tx, *destinationParentID, folderID)
+			if err != nil {
+				return err
+			}
+			if isDescendant {
+				return ErrMoveIntoDescendant
+			}
+		}
+
+		resolution, err := resolveNameConflict(tx, userID, destinationParentID, name, conflictPolicy, false, folderID)
+		if err != nil {
+			return err
+		}
+
+		folder.ParentFolderID = destinationParentID
+		folder.Name = resolution.name
+		return tx.Model(&folder).Updates(map[string]interface{}{
+			"parent_folder_id": destinationParentID,
+			"name":             resolution.name,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// lockUserTree takes a row lock on the user so that structural changes to
+// the user's folder tree are applied one transaction at a time
+func lockUserTree(tx *gorm.DB, userID string) error {
+	return tx.Exec("SELECT 1 FROM users WHERE id = ? FOR UPDATE", userID).Error
+}
+
+// ensureFolderOwned checks inside a transaction that a folder exists and belongs to the user
+func ensureFolderOwned(tx *gorm.DB, folderID string, userID string) error {
+	var count int64
+	if err := tx.Model(&models.Folder{}).Where("id = ? AND user_id = ?", folderID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrDestinationNotFound
+	}
+	return nil
+}
+
+// isSameOrDescendantFolder reports whether candidateID is folderID itself or lies somewhere below it,
+// by walking the ancestors of candidateID with a recursive CTE
+func isSameOrDescendantFolder(tx *gorm.DB, candidateID string, folderID string) (bool, error) {
+	var found bool
+	err := tx.Raw(`WITH RECURSIVE ancestors AS (
+		SELECT id, parent_folder_id FROM folders WHERE id = ?
+		UNION
+		SELECT f.id, f.parent_folder_id FROM folders f JOIN ancestors a ON f.id = a.parent_folder_id
+	) SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = ?)`, candidateID, folderID).Scan(&found).Error
+	return found, err
+}
+
+// ResolveFolderPath walks a user's folder tree along the given path segments with a single
+// recursive CTE and returns the ID of the folder the path points to
+//
+// Parameters:
+//   - userID: The ID of the user who owns the folder tree
+//   - segments: The folder names from the root downwards; empty for the root itself
+//
+// Returns:
+//   - The ID of the folder, or nil when segments is empty (the user's root)
+//   - gorm.ErrRecordNotFound if any segment of the path does not exist
+func ResolveFolderPath(userID string, segments []string) (*string, error) {
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	values := make([]string, len(segments))
+	args := make([]interface{}, 0, len(segments)+2)
+	for i, segment := range segments {
+		values[i] = fmt.Sprintf("(%d, ?::text)", i+1)
+		args = append(args, segment)
+	}
+	args = append(args, userID, len(segments))
+
+	query := fmt.Sprintf(`WITH RECURSIVE segments(depth, name) AS (VALUES %s),
+	walk(depth, id) AS (
+		SELECT 1, f.id FROM folders f JOIN segments s ON s.depth = 1
+		WHERE f.user_id = ? AND f.parent_folder_id IS NULL AND f.name = s.name AND f.deleted_at IS NULL
+		UNION ALL
+		SELECT w.depth + 1, f.id FROM walk w
+		JOIN segments s ON s.depth = w.depth + 1
+		JOIN folders f ON f.parent_folder_id = w.id AND f.name = s.name AND f.deleted_at IS NULL
+	) SELECT id FROM walk WHERE depth = ? LIMIT 1`, strings.Join(values, ", "))
+
+	var ids []string
+	if err := db.DB.Raw(query, args...).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &ids[0], nil
+}
+
+// FindFolderByName retrieves the folder with the given name directly inside a parent folder
+//
+// Parameters:
+//   - userID: The ID of the user who owns the folder tree
+//   - parentFolderID: The ID of the parent folder, or nil for the user's root
+//   - name: The name of the folder to find
+//
+// Returns:
+//   - A pointer to the Folder model if found
+//   - gorm.ErrRecordNotFound if no such folder exists
+func FindFolderByName(userID string, parentFolderID *string, name string) (*models.Folder, error) {
+	query := db.DB.Where("user_id = ? AND name = ?", userID, name)
+	if parentFolderID == nil {
+		query = query.Where("parent_folder_id IS NULL")
+	} else {
+		query = query.Where("parent_folder_id = ?", *parentFolderID)
+	}
+
+	var folder models.Folder
+	if err := query.First(&folder).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FolderStats holds aggregate figures for everything below a folder
+type FolderStats struct {
+	FolderID    string
+	TotalBytes  int64 // combined size of the current versions of all completed files
+	FileCount   int64 // completed files at any depth
+	FolderCount int64 // subfolders at any depth
+}
+
+// GetFolderStats computes the recursive size and item counts of several live folders at once.
+// Trashed items and files whose first upload has not completed are not counted.
+//
+// Parameters:
+//   - userID: The ID of the user who owns the folders
+//   - folderIDs: The IDs of the folders to aggregate
+//
+// Returns:
+//   - A map from folder ID to its FolderStats; folders that do not exist are omitted
+//   - An error if the database operation fails
+func GetFolderStats(userID string, folderIDs []string) (map[string]FolderStats, error) {
+	stats := make(map[string]FolderStats, len(folderIDs))
+	if len(folderIDs) == 0 {
+		return stats, nil
+	}
+
+	var rows []FolderStats
+	err := db.DB.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id AS root_id, id FROM folders WHERE id IN ? AND user_id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT s.root_id, f.id FROM folders f JOIN subtree s ON f.parent_folder_id = s.id WHERE f.deleted_at IS NULL
+	) SELECT s.root_id AS folder_id,
+		COALESCE(SUM(fi.size), 0) AS total_bytes,
+		COUNT(fi.id) AS file_count,
+		COUNT(DISTINCT s.id) - 1 AS folder_count
+	FROM subtree s
+	LEFT JOIN files fi ON fi.folder_id = s.id AND fi.deleted_at IS NULL AND fi.status = ?
+	GROUP BY s.root_id`, folderIDs, userID, models.FileStatusComplete).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		stats[row.FolderID] = row
+	}
+	return stats, nil
+}
+
+// GetRootStats computes the size and item counts of a user's whole live folder tree
+//
+// Parameters:
+//   - userID: The ID of the user
+//
+// Returns:
+//   - The FolderStats of the user's root (FolderID is empty)
+//   - An error if the database operation fails
+func GetRootStats(userID string) (*FolderStats, error) {
+	var stats FolderStats
+	err := db.DB.Raw(`SELECT
+		(SELECT COALESCE(SUM(size), 0) FROM files WHERE user_id = ? AND deleted_at IS NULL AND status = ?) AS total_bytes,
+		(SELECT COUNT(*) FROM files WHERE user_id = ? AND deleted_at IS NULL AND status = ?) AS file_count,
+		(SELECT COUNT(*) FROM folders WHERE user_id = ? AND deleted_at IS NULL) AS folder_count`,
+		userID, models.FileStatusComplete, userID, models.FileStatusComplete, userID).Scan(&stats).Error
+	return &stats, err
+}
@@ -0,0 +1,175 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+)
+
+// Conflict policies applied when an item is created, moved or renamed onto a name
+// that is already taken inside the destination folder
+const (
+	ConflictPolicyFail      = "fail"      // reject the operation
+	ConflictPolicyRename    = "rename"    // pick the next free name, e.g. "report (1).pdf"
+	ConflictPolicyOverwrite = "overwrite" // replace the existing file (files only)
+)
+
+// maxAutoRenameAttempts bounds the search for a free "name (n)" variant
+const maxAutoRenameAttempts = 10000
+
+var (
+	// ErrNameConflict is returned when the destination folder already contains an item with the same name
+	ErrNameConflict = errors.New("an item with the same name already exists in the destination folder")
+	// ErrInvalidConflictPolicy is returned for unknown policies or policies not applicable to the item
+	ErrInvalidConflictPolicy = errors.New("invalid conflict policy")
+)
+
+// IsValidConflictPolicy reports whether the policy is one of the supported conflict policies.
+// An empty policy is treated as ConflictPolicyFail.
+func IsValidConflictPolicy(policy string) bool {
+	switch policy {
+	case "", ConflictPolicyFail, ConflictPolicyRename, ConflictPolicyOverwrite:
+		return true
+	}
+	return false
+}
+
+// nameResolution is the outcome of applying a conflict policy to a requested name
+type nameResolution struct {
+	name          string  // the name the item should be stored under
+	replaceFileID *string // an existing file to move to the trash first (overwrite policy)
+}
+
+// resolveNameConflict decides, inside a transaction holding the user tree lock, which name an item
+// may use in a folder. Files and folders share a namespace so that paths stay unambiguous.
+//
+// Parameters:
+//   - tx: The transaction the item is being written in
+//   - userID: The ID of the user who owns the folder tree
+//   - parentID: The destination folder ID, or nil for the user's root
+//   - name: The requested name
+//   - policy: One of the ConflictPolicy constants (empty means fail)
+//   - isFile: Whether the item being written is a file; only files can overwrite
+//   - selfID: The ID of the item being moved or renamed, which never conflicts with itself
+//     (empty for new items)
+//
+// Returns:
+//   - The name to use and, for the overwrite policy, the file that must be replaced
+//   - ErrNameConflict or ErrInvalidConflictPolicy if the item cannot be written under that name
+func resolveNameConflict(tx *gorm.DB, userID string, parentID *string, name, policy string, isFile bool, selfID string) (*nameResolution, error) {
+	if policy == "" {
+		policy = ConflictPolicyFail
+	}
+	if !IsValidConflictPolicy(policy) || (policy == ConflictPolicyOverwrite && !isFile) {
+		return nil, ErrInvalidConflictPolicy
+	}
+
+	folder, file, err := findNamedChild(tx, userID, parentID, name, selfID)
+	if err != nil {
+		return nil, err
+	}
+	if folder == nil && file == nil {
+		return &nameResolution{name: name}, nil
+	}
+
+	switch policy {
+	case ConflictPolicyOverwrite:
+		// Only a file can be replaced; a folder with the same name is always a conflict
+		if file == nil {
+			return nil, ErrNameConflict
+		}
+		return &nameResolution{name: name, replaceFileID: &file.ID}, nil
+	case ConflictPolicyRename:
+		freeName, err := nextFreeName(tx, userID, parentID, name)
+		if err != nil {
+			return nil, err
+		}
+		return &nameResolution{name: freeName}, nil
+	default:
+		return nil, ErrNameConflict
+	}
+}
+
+// findNamedChild looks up a folder or file with the given name directly inside a parent folder,
+// ignoring the item identified by selfID
+func findNamedChild(tx *gorm.DB, userID string, parentID *string, name, selfID string) (*models.Folder, *models.File, error) {
+	folderQuery := tx.Where("user_id = ? AND name = ?", userID, name)
+	fileQuery := tx.Where("user_id = ? AND name = ?", userID, name)
+	if parentID == nil {
+		folderQuery = folderQuery.Where("parent_folder_id IS NULL")
+		fileQuery = fileQuery.Where("folder_id IS NULL")
+	} else {
+		folderQuery = folderQuery.Where("parent_folder_id = ?", *parentID)
+		fileQuery = fileQuery.Where("folder_id = ?", *parentID)
+	}
+	if selfID != "" {
+		folderQuery = folderQuery.Where("id <> ?", selfID)
+		fileQuery = fileQuery.Where("id <> ?", selfID)
+	}
+
+	var folders []models.Folder
+	if err := folderQuery.Limit(1).Find(&folders).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(folders) > 0 {
+		return &folders[0], nil, nil
+	}
+
+	var files []models.File
+	if err := fileQuery.Limit(1).Find(&files).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(files) > 0 {
+		return nil, &files[0], nil
+	}
+	return nil, nil, nil
+}
+
+// nextFreeName returns the first "base (n)ext" variant of name that is not used by any folder or file
+// in the parent folder, e.g. "report (1).pdf" for "report.pdf"
+func nextFreeName(tx *gorm.DB, userID string, parentID *string, name string) (string, error) {
+	ext := filepath.Ext(name)
+	if ext == name {
+		// Dot-files such as ".env" have no base name to number
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+
+	// Load every existing "base (…" sibling name once instead of probing one candidate at a time
+	pattern := escapeLike(base) + ` (%`
+	folderFilter, fileFilter := "parent_folder_id IS NULL", "folder_id IS NULL"
+	args := []interface{}{userID, pattern, userID, pattern}
+	if parentID != nil {
+		folderFilter, fileFilter = "parent_folder_id = ?", "folder_id = ?"
+		args = []interface{}{userID, pattern, *parentID, userID, pattern, *parentID}
+	}
+	var taken []string
+	err := tx.Raw(fmt.Sprintf(`SELECT name FROM folders WHERE user_id = ? AND name LIKE ? AND %s AND deleted_at IS NULL
+		UNION SELECT name FROM files WHERE user_id = ? AND name LIKE ? AND %s AND deleted_at IS NULL`, folderFilter, fileFilter), args...).
+		Scan(&taken).Error
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]struct{}, len(taken))
+	for _, existing := range taken {
+		used[existing] = struct{}{}
+	}
+	for n := 1; n <= maxAutoRenameAttempts; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if _, exists := used[candidate]; !exists {
+			return candidate, nil
+		}
+	}
+	return "", ErrNameConflict
+}
+
+// escapeLike escapes the LIKE wildcard characters in a literal string
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,88 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTooManyOAuthStates is returned when a client already has the maximum number of pending OAuth states
+var ErrTooManyOAuthStates = errors.New("too many pending OAuth states")
+
+// CreateOAuthState stores a pending OAuth state unless its requester already has maxPerRequester
+// unexpired states. Once more than maxPending states exist in total, the oldest ones are evicted,
+// so a single client can neither fill the table nor block sign-ins for everyone else.
+// The per-requester limit is checked before inserting, so concurrent requests may exceed it slightly.
+//
+// Parameters:
+//   - state: The OAuth state to store
+//   - maxPending: The maximum number of states kept in total
+//   - maxPerRequester: The maximum number of unexpired states per requester
+//
+// Returns:
+//   - ErrTooManyOAuthStates if the requester has reached its limit
+//   - An error if the database operation fails
+func CreateOAuthState(state *models.OAuthState, maxPending int, maxPerRequester int) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var pending int64
+		err := tx.Model(&models.OAuthState{}).
+			Where("requester = ? AND expires_at > ?", state.Requester, time.Now()).
+			Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending >= int64(maxPerRequester) {
+			return ErrTooManyOAuthStates
+		}
+		if err := tx.Create(state).Error; err != nil {
+			return err
+		}
+		// States share one TTL, so the earliest expiry marks the oldest state
+		return tx.Exec(`
+			DELETE FROM oauth_states
+			WHERE state IN (
+				SELECT state FROM oauth_states
+				ORDER BY expires_at DESC
+				OFFSET ?
+			)`, maxPending).Error
+	})
+}
+
+// ConsumeOAuthState deletes an unexpired OAuth state and returns it. Deleting and reading in one
+// statement guarantees that a state is accepted at most once, even across backend instances.
+//
+// Parameters:
+//   - state: The state token presented by the OAuth callback
+//
+// Returns:
+//   - A pointer to the consumed OAuthState model
+//   - gorm.ErrRecordNotFound if the state is unknown, already consumed or expired
+//   - An error if the database operation fails
+func ConsumeOAuthState(state string) (*models.OAuthState, error) {
+	var consumed []models.OAuthState
+	err := db.DB.Clauses(clause.Returning{}).
+		Where("state = ? AND expires_at > ?", state, time.Now()).
+		Delete(&consumed).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(consumed) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &consumed[0], nil
+}
+
+// DeleteExpiredOAuthStates removes OAuth states whose callback never arrived in time
+//
+// Returns:
+//   - The number of states deleted
+//   - An error if the database operation fails
+func DeleteExpiredOAuthStates() (int64, error) {
+	result := db.DB.Where("expires_at <= ?", time.Now()).Delete(&models.OAuthState{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,95 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned when a refresh token is unknown, expired or revoked
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	// ErrRefreshTokenReused is returned when an already used refresh token is presented again
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same session and records
+// the session as seen from ipAddress. The presented token is marked as used. If it had already
+// been used, the token was presented twice, which only happens when it leaked, so the whole
+// session is revoked and ErrRefreshTokenReused is returned.
+//
+// Parameters:
+//   - tokenHash: The SHA-256 hash of the presented token, hex-encoded
+//   - newTokenHash: The SHA-256 hash of the replacement token, hex-encoded
+//   - expiresAt: When the replacement token stops being accepted
+//   - ipAddress: The IP address of the client refreshing the session
+//
+// Returns:
+//   - A pointer to the replacement RefreshToken model
+//   - ErrRefreshTokenInvalid if the presented token is unknown, expired or revoked
+//   - ErrRefreshTokenReused if the presented token had already been used
+//   - An error if the database operation fails
+func RotateRefreshToken(tokenHash string, newTokenHash string, expiresAt time.Time, ipAddress string) (*models.RefreshToken, error) {
+	var replacement *models.RefreshToken
+	reused := false
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var current models.RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", tokenHash).First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+		if current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+			return ErrRefreshTokenInvalid
+		}
+		if current.UsedAt != nil {
+			// Commit the revocation before reporting the reuse
+			reused = true
+			return revokeSession(tx, current.FamilyID, now)
+		}
+
+		// Tokens of a revoked session, or issued before sessions existed, cannot be refreshed
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", current.FamilyID).
+			Updates(map[string]interface{}{
+				"ip_address":   ipAddress,
+				"last_seen_at": now,
+				"expires_at":   expiresAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err := tx.Model(&current).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		replacement = &models.RefreshToken{
+			ID:        uuid.New().String(),
+			UserID:    current.UserID,
+			FamilyID:  current.FamilyID,
+			TokenHash: newTokenHash,
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		}
+		return tx.Create(replacement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return replacement, nil
+}
@@ -0,0 +1,151 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+)
+
+// SearchQuery describes a name search over a user's live files and folders
+type SearchQuery struct {
+	Text          string     // matched against names as a substring or fuzzily (trigram word similarity)
+	Kind          *int       // EntryKindFolder or EntryKindFile to restrict the result type, or nil for both
+	MinSize       *int64     // files only; setting a size bound excludes folders
+	MaxSize       *int64     // files only; setting a size bound excludes folders
+	CreatedAfter  *time.Time // inclusive lower bound on the creation time
+	CreatedBefore *time.Time // exclusive upper bound on the creation time
+	FolderID      *string    // restrict results to items below this folder, at any depth
+	Tags          []string   // files only; every result must carry all of these tags
+	Limit         int
+	Offset        int
+}
+
+// SearchResult is a single file or folder matched by SearchItems
+type SearchResult struct {
+	Kind      int
+	ID        string
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+	ParentID  *string
+	Score     float64 // trigram word similarity between the query and the name, from 0 to 1
+}
+
+// SearchItems finds a user's live files and folders by name. Names containing the query text are
+// ranked first, followed by fuzzy matches ordered by similarity. Both branches of the search are
+// served by the trigram indexes on files.name and folders.name.
+//
+// Parameters:
+//   - userID: The ID of the user whose items are searched
+//   - query: The search text and filters
+//
+// Returns:
+//   - The matching items of the requested page
+//   - An error if the database operation fails
+func SearchItems(userID string, query *SearchQuery) ([]SearchResult, error) {
+	pattern := "%" + escapeLike(query.Text) + "%"
+	nameMatch := "(name ILIKE ? OR ? <% name)"
+
+	var conditions []string
+	var args []interface{}
+	filter := func(condition string, values ...interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+	if query.MinSize != nil {
+		filter("size >= ?", *query.MinSize)
+	}
+	if query.MaxSize != nil {
+		filter("size <= ?", *query.MaxSize)
+	}
+	if query.CreatedAfter != nil {
+		filter("created_at >= ?", *query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		filter("created_at < ?", *query.CreatedBefore)
+	}
+	if query.FolderID != nil {
+		filter(`parent_id IN (WITH RECURSIVE scope AS (
+			SELECT id FROM folders WHERE id = ? AND user_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT f.id FROM folders f JOIN scope s ON f.parent_folder_id = s.id WHERE f.deleted_at IS NULL
+		) SELECT id FROM scope)`, *query.FolderID, userID)
+	}
+	if len(query.Tags) > 0 {
+		filter("id IN (?)", taggedFileIDs(db.DB, query.Tags))
+	}
+
+	// Size bounds and tags only make sense for files
+	includeFolders := query.MinSize == nil && query.MaxSize == nil && len(query.Tags) == 0 &&
+		(query.Kind == nil || *query.Kind == EntryKindFolder)
+	includeFiles := query.Kind == nil || *query.Kind == EntryKindFile
+
+	var branches []string
+	var branchArgs []interface{}
+	if includeFolders {
+		branches = append(branches, fmt.Sprintf(`SELECT %d AS kind, id, name, 0::bigint AS size, created_at, parent_folder_id AS parent_id
+			FROM folders WHERE user_id = ? AND deleted_at IS NULL AND %s`, EntryKindFolder, nameMatch))
+		branchArgs = append(branchArgs, userID, pattern, query.Text)
+	}
+	if includeFiles {
+		branches = append(branches, fmt.Sprintf(`SELECT %d AS kind, id, name, size, created_at, folder_id AS parent_id
+			FROM files WHERE user_id = ? AND deleted_at IS NULL AND status = ? AND %s`, EntryKindFile, nameMatch))
+		branchArgs = append(branchArgs, userID, models.FileStatusComplete, pattern, query.Text)
+	}
+	if len(branches) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	sql := fmt.Sprintf(`SELECT kind, id, name, size, created_at, parent_id, word_similarity(?, name) AS score
+		FROM (%s) items`, strings.Join(branches, " UNION ALL "))
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sql += " ORDER BY (name ILIKE ?) DESC, score DESC, name ASC, id ASC LIMIT ? OFFSET ?"
+
+	allArgs := append([]interface{}{query.Text}, branchArgs...)
+	allArgs = append(allArgs, args...)
+	allArgs = append(allArgs, pattern, query.Limit, query.Offset)
+
+	var results []SearchResult
+	err := db.DB.Raw(sql, allArgs...).Scan(&results).Error
+	return results, err
+}
+
+// FindFolderPaths builds the slash-separated path from the user's root of several folders at once,
+// e.g. "/photos/2024"
+//
+// Parameters:
+//   - folderIDs: The IDs of the folders
+//
+// Returns:
+//   - A map from folder ID to its path; folders that do not exist are omitted
+//   - An error if the database operation fails
+func FindFolderPaths(folderIDs []string) (map[string]string, error) {
+	paths := make(map[string]string, len(folderIDs))
+	if len(folderIDs) == 0 {
+		return paths, nil
+	}
+
+	var rows []struct {
+		FolderID string
+		Path     string
+	}
+	err := db.DB.Raw(`WITH RECURSIVE chain AS (
+		SELECT id AS folder_id, parent_folder_id, name, 0 AS depth FROM folders WHERE id IN ?
+		UNION ALL
+		SELECT c.folder_id, f.parent_folder_id, f.name, c.depth + 1 FROM folders f JOIN chain c ON f.id = c.parent_folder_id
+	) SELECT folder_id, '/' || string_agg(name, '/' ORDER BY depth DESC) AS path FROM chain GROUP BY folder_id`, folderIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		paths[row.FolderID] = row.Path
+	}
+	return paths, nil
+}
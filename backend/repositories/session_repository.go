@@ -0,0 +1,181 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateSession starts a new session for a user together with its first refresh token
+//
+// Parameters:
+//   - userID: The ID of the user signing in
+//   - device: The User-Agent of the client signing in
+//   - ipAddress: The IP address of the client signing in
+//   - tokenHash: The SHA-256 hash of the first refresh token, hex-encoded
+//   - expiresAt: When the first refresh token stops being accepted
+//
+// Returns:
+//   - A pointer to the created Session model
+//   - An error if the database operation fails
+func CreateSession(userID string, device string, ipAddress string, tokenHash string, expiresAt time.Time) (*models.Session, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Device:     device,
+		IPAddress:  ipAddress,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.RefreshToken{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			FamilyID:  session.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// FindActiveSession retrieves a session of a user that has neither been revoked nor expired
+//
+// Parameters:
+//   - sessionID: The ID of the session (the jti claim of its access tokens)
+//   - userID: The ID of the user the session must belong to
+//
+// Returns:
+//   - A pointer to the Session model if found
+//   - gorm.ErrRecordNotFound if the session does not exist, belongs to another user, or is no longer active
+func FindActiveSession(sessionID string, userID string) (*models.Session, error) {
+	var session models.Session
+	err := db.DB.
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, time.Now()).
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveSessions retrieves the active sessions of a user, most recently seen first
+//
+// Parameters:
+//   - userID: The ID of the user
+//
+// Returns:
+//   - A slice of Session models
+//   - An error if the database operation fails
+func ListActiveSessions(userID string) ([]models.Session, error) {
+	var sessions []models.Session
+	err := db.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession records that a session was just used from ipAddress
+//
+// Parameters:
+//   - sessionID: The ID of the session
+//   - ipAddress: The IP address of the client using the session
+//
+// Returns:
+//   - An error if the database operation fails
+func TouchSession(sessionID string, ipAddress string) error {
+	return db.DB.Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		Updates(map[string]interface{}{
+			"ip_address":   ipAddress,
+			"last_seen_at": time.Now(),
+		}).Error
+}
+
+// RevokeSession revokes an active session of a user and all of its refresh tokens.
+// Access tokens issued for the session are rejected from then on.
+//
+// Parameters:
+//   - sessionID: The ID of the session
+//   - userID: The ID of the user the session must belong to
+//
+// Returns:
+//   - gorm.ErrRecordNotFound if the user has no such active session
+//   - An error if the database operation fails
+func RevokeSession(sessionID string, userID string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		err := tx.Select("id").
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+			First(&session).Error
+		if err != nil {
+			return err
+		}
+		return revokeSession(tx, session.ID, time.Now())
+	})
+}
+
+// RevokeUserSessions revokes every active session of a user except, optionally, one
+//
+// Parameters:
+//   - userID: The ID of the user
+//   - exceptSessionID: The ID of a session to keep, or an empty string to revoke all of them
+//
+// Returns:
+//   - The number of sessions revoked
+//   - An error if the database operation fails
+func RevokeUserSessions(userID string, exceptSessionID string) (int64, error) {
+	var revoked int64
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		if exceptSessionID != "" {
+			query = query.Where("id <> ?", exceptSessionID)
+		}
+		result := query.Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		revoked = result.RowsAffected
+
+		query = tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		if exceptSessionID != "" {
+			query = query.Where("family_id <> ?", exceptSessionID)
+		}
+		return query.Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return revoked, nil
+}
+
+// revokeSession revokes a session and every refresh token of it that is not revoked yet
+func revokeSession(tx *gorm.DB, sessionID string, now time.Time) error {
+	err := tx.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", now).Error
+}
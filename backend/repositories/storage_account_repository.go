@@ -0,0 +1,190 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrStorageAccountNotEmpty is returned when a storage account still holds chunks
+var ErrStorageAccountNotEmpty = errors.New("storage account still holds chunks")
+
+// BranchBlob is a blob stored in a branch, shared by every chunk with the same Git path
+type BranchBlob struct {
+	GitPath string
+	Size    int64
+}
+
+// storageAccountLockClass namespaces the advisory locks taken on the storage accounts of a user
+const storageAccountLockClass = 41
+
+// WithStorageAccountLock runs fn while holding an exclusive lock on the storage accounts of a
+// user. Emptying and unlinking an account runs under this lock, so two unlinks of the same user
+// cannot move data into each other's accounts, and uploads do not deduplicate against chunks
+// stored in an account while it is being emptied (see tryLockStorageAccountsShared).
+//
+// Parameters:
+//   - userID: The ID of the user whose storage accounts are locked
+//   - fn: The work to do while the lock is held
+//
+// Returns:
+//   - The error returned by fn
+//   - An error if the lock cannot be taken
+func WithStorageAccountLock(userID string, fn func() error) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", storageAccountLockClass, userID).Error; err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+// tryLockStorageAccountsShared takes a shared lock on the storage accounts of a user for the rest
+// of the transaction, without waiting. It fails to lock only while an account is being emptied.
+func tryLockStorageAccountsShared(tx *gorm.DB, userID string) (bool, error) {
+	var locked bool
+	err := tx.Raw("SELECT pg_try_advisory_xact_lock_shared(?, hashtext(?))", storageAccountLockClass, userID).Scan(&locked).Error
+	return locked, err
+}
+
+// FindStorageTokenByAccount retrieves a GitHub storage account of a user by platform and account.
+// OAuth accounts ("GITHUB") are identified by the GitHub login and app installations ("GITHUB_APP")
+// by the user or organization the app is installed on, so the same name can belong to one of each.
+//
+// Parameters:
+//   - userID: The ID of the user
+//   - platform: "GITHUB" or "GITHUB_APP"
+//   - account: The GitHub login or installation account
+//
+// Returns:
+//   - A pointer to the Token model if found
+//   - gorm.ErrRecordNotFound if the user has not linked that account
+func FindStorageTokenByAccount(userID string, platform string, account string) (*models.Token, error) {
+	var token models.Token
+	err := db.DB.Where("user_id = ? AND platform = ? AND account_identifier = ?", userID, platform, account).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetStorageBranches retrieves the branches of the repositories of the given storage accounts
+// The repository and token of each branch are preloaded so the branch can be accessed on GitHub
+//
+// Parameters:
+//   - tokenIDs: The IDs of the storage accounts
+//
+// Returns:
+//   - A slice of Branch models
+//   - An error if the database operation fails
+func GetStorageBranches(tokenIDs []string) ([]models.Branch, error) {
+	var branches []models.Branch
+	if len(tokenIDs) == 0 {
+		return branches, nil
+	}
+	err := db.DB.Preload("Repo.Token").
+		Where("repo_id IN (?)", db.DB.Model(&models.Repo{}).Select("id").Where("token_id IN ?", tokenIDs)).
+		Order("created_at").
+		Find(&branches).Error
+	return branches, err
+}
+
+// GetReposForToken retrieves the storage repositories of a storage account
+//
+// Parameters:
+//   - tokenID: The ID of the storage account's token
+//
+// Returns:
+//   - A slice of Repo models
+//   - An error if the database operation fails
+func GetReposForToken(tokenID string) ([]models.Repo, error) {
+	var repos []models.Repo
+	err := db.DB.Where("token_id = ?", tokenID).Find(&repos).Error
+	return repos, err
+}
+
+// GetBranchBlobs retrieves the distinct blobs that chunks reference in a branch
+//
+// Parameters:
+//   - branchID: The ID of the branch
+//
+// Returns:
+//   - A slice of BranchBlob values
+//   - An error if the database operation fails
+func GetBranchBlobs(branchID string) ([]BranchBlob, error) {
+	var blobs []BranchBlob
+	err := db.DB.Raw(`SELECT DISTINCT ON (git_path) git_path, size FROM chunks
+		WHERE branch_id = ? AND git_path IS NOT NULL`, branchID).Scan(&blobs).Error
+	return blobs, err
+}
+
+// MoveBlob records that a blob has been copied to another branch: every chunk referencing it is
+// pointed at the new branch and the usage of both repositories is adjusted
+//
+// Parameters:
+//   - fromBranch: The branch the blob was copied from
+//   - toBranch: The branch the blob was copied to, under the same Git path
+//   - blob: The moved blob
+//
+// Returns:
+//   - The number of chunks moved; 0 if they were all deleted in the meantime
+//   - An error if the database operation fails
+func MoveBlob(fromBranch *models.Branch, toBranch *models.Branch, blob BranchBlob) (int64, error) {
+	var moved int64
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Chunk{}).
+			Where("branch_id = ? AND git_path = ?", fromBranch.ID, blob.GitPath).
+			Update("branch_id", toBranch.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+		if moved == 0 {
+			return nil
+		}
+		if err := tx.Exec(`UPDATE repos SET used_bytes = GREATEST(used_bytes - ?, 0) WHERE id = ?`,
+			blob.Size, fromBranch.RepoID).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE repos SET used_bytes = used_bytes + ? WHERE id = ?`, blob.Size, toBranch.RepoID).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// DeleteStorageAccount deletes a storage account together with the records of its repositories
+// and branches. The repositories themselves are left on GitHub.
+//
+// Parameters:
+//   - tokenID: The ID of the storage account's token
+//
+// Returns:
+//   - ErrStorageAccountNotEmpty if chunks still reference one of its branches
+//   - An error if the database operation fails
+func DeleteStorageAccount(tokenID string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		repoIDs := tx.Model(&models.Repo{}).Select("id").Where("token_id = ?", tokenID)
+		branchIDs := tx.Model(&models.Branch{}).Select("id").Where("repo_id IN (?)", repoIDs)
+
+		var remaining int64
+		if err := tx.Model(&models.Chunk{}).Where("branch_id IN (?)", branchIDs).Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return ErrStorageAccountNotEmpty
+		}
+
+		if err := tx.Where("repo_id IN (?)", repoIDs).Delete(&models.Branch{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("token_id = ?", tokenID).Delete(&models.Repo{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", tokenID).Delete(&models.Token{}).Error
+	})
+}
@@ -0,0 +1,139 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/crypto_util"
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	// Fields tagged serializer:encrypted are encrypted on every write and decrypted on every read,
+	// including when tokens are preloaded as associations of repos and chunks
+	schema.RegisterSerializer("encrypted", encryptedSerializer{})
+}
+
+// encryptedSerializer stores string and *string fields encrypted with crypto_util
+type encryptedSerializer struct{}
+
+// Scan decrypts a column value into the field
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType).Elem()
+	if dbValue != nil {
+		var stored string
+		switch v := dbValue.(type) {
+		case string:
+			stored = v
+		case []byte:
+			stored = string(v)
+		default:
+			return fmt.Errorf("unsupported value %T for encrypted field %s", dbValue, field.Name)
+		}
+
+		plaintext, err := crypto_util.Decrypt(stored)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+		}
+		if field.FieldType.Kind() == reflect.Ptr {
+			fieldValue.Set(reflect.ValueOf(&plaintext))
+		} else {
+			fieldValue.SetString(plaintext)
+		}
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue)
+	return nil
+}
+
+// Value encrypts the field for storage; nil pointers are stored as NULL
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	switch v := fieldValue.(type) {
+	case string:
+		return crypto_util.Encrypt(v)
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		return crypto_util.Encrypt(*v)
+	}
+	return nil, fmt.Errorf("unsupported type %T for encrypted field %s", fieldValue, field.Name)
+}
+
+// ReencryptTokens re-encrypts the access and refresh tokens of every stored token with the
+// current encryption key. Tokens still stored in plaintext are encrypted as well, and tokens
+// that are already encrypted with the current key are left untouched. A token that changes
+// between being read and rewritten, e.g. by an OAuth refresh, is skipped rather than overwritten.
+//
+// Parameters:
+//   - batchSize: The number of tokens loaded at a time
+//
+// Returns:
+//   - The number of tokens that were rewritten
+//   - An error if a token cannot be decrypted or the database operation fails
+func ReencryptTokens(batchSize int) (int, error) {
+	type storedToken struct {
+		ID           string
+		AccessToken  string
+		RefreshToken *string
+	}
+
+	rewritten := 0
+	lastID := ""
+	for {
+		// Raw queries bypass the serializer, so the stored ciphertext is read as it is
+		var batch []storedToken
+		err := db.DB.Raw(`SELECT id, access_token, refresh_token FROM tokens WHERE id::text > ? ORDER BY id::text LIMIT ?`, lastID, batchSize).
+			Scan(&batch).Error
+		if err != nil {
+			return rewritten, err
+		}
+		if len(batch) == 0 {
+			return rewritten, nil
+		}
+
+		for _, token := range batch {
+			lastID = token.ID
+			if crypto_util.IsCurrentKey(token.AccessToken) && (token.RefreshToken == nil || crypto_util.IsCurrentKey(*token.RefreshToken)) {
+				continue
+			}
+
+			accessToken, err := reencrypt(token.AccessToken)
+			if err != nil {
+				return rewritten, fmt.Errorf("token %s: %w", token.ID, err)
+			}
+			var refreshToken *string
+			if token.RefreshToken != nil {
+				value, err := reencrypt(*token.RefreshToken)
+				if err != nil {
+					return rewritten, fmt.Errorf("token %s: %w", token.ID, err)
+				}
+				refreshToken = &value
+			}
+
+			// Only overwrite the ciphertext that was read, so a token refreshed in the meantime is kept
+			result := db.DB.Exec(`UPDATE tokens SET access_token = ?, refresh_token = ?
+				WHERE id = ? AND access_token = ? AND refresh_token IS NOT DISTINCT FROM ?`,
+				accessToken, refreshToken, token.ID, token.AccessToken, token.RefreshToken)
+			if result.Error != nil {
+				return rewritten, result.Error
+			}
+			if result.RowsAffected == 0 {
+				// A refreshed token is written with the current key already
+				continue
+			}
+			rewritten++
+		}
+	}
+}
+
+// reencrypt decrypts a stored value with whichever key sealed it and encrypts it with the current key
+func reencrypt(stored string) (string, error) {
+	plaintext, err := crypto_util.Decrypt(stored)
+	if err != nil {
+		return "", err
+	}
+	return crypto_util.Encrypt(plaintext)
+}
@@ -235,3 +235,83 @@ func GetTokenByID(tokenID string) (*models.Token, error) {
 	}
 	return &token, nil
 }
+
+// GetStorageTokensForUser retrieves every token a user stores data with:
+// GitHub OAuth accounts and GitHub App installations
+//
+// Parameters:
+//   - userID: The ID of the user
+//
+// Returns:
+//   - A slice of Token models with platform "GITHUB" or "GITHUB_APP"
+//   - An error if the database operation fails
+func GetStorageTokensForUser(userID string) ([]models.Token, error) {
+	var tokens []models.Token
+	err := db.DB.Where("user_id = ? AND platform IN ?", userID, []string{"GITHUB", "GITHUB_APP"}).Find(&tokens).Error
+	return tokens, err
+}
+
+// CreateOrUpdateGitHubAppToken stores a GitHub App installation linked by a user as a storage account.
+// Linking an installation for the same GitHub account again replaces the stored installation.
+//
+// Parameters:
+//   - userID: The ID of the user this token belongs to
+//   - accountLogin: The login of the user or organization the app is installed on, used as the
+//     account identifier
+//   - installationID: The GitHub App installation ID
+//   - accessToken: The current installation access token
+//   - accessTokenExpiry: The expiration time of the installation access token
+//
+// Returns:
+//   - A pointer to the created or updated Token model
+//   - An error if the database operation fails
+func CreateOrUpdateGitHubAppToken(
+	userID string,
+	accountLogin string,
+	installationID int64,
+	accessToken string,
+	accessTokenExpiry time.Time) (*models.Token, error) {
+	now := time.Now()
+	var token models.Token
+	result := db.DB.Where("user_id = ? AND platform = ? AND account_identifier = ?", userID, "GITHUB_APP", accountLogin).First(&token)
+	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+		return nil, result.Error
+	}
+	if result.Error == gorm.ErrRecordNotFound {
+		token = models.Token{
+			ID:                uuid.New().String(),
+			UserID:            userID,
+			Platform:          "GITHUB_APP",
+			AccountIdentifier: &accountLogin,
+			CreatedAt:         now,
+		}
+	}
+
+	token.InstallationID = &installationID
+	token.AccessToken = accessToken
+	token.AccessTokenExpiry = &accessTokenExpiry
+	token.AccessTokenIssuedAt = now
+	token.UpdatedAt = now
+	return &token, db.DB.Save(&token).Error
+}
+
+// UpdateInstallationAccessToken stores a freshly minted access token of a GitHub App installation
+//
+// Parameters:
+//   - token: The installation's token, updated in place
+//   - accessToken: The new installation access token
+//   - accessTokenExpiry: The expiration time of the new access token
+//
+// Returns:
+//   - An error if the database operation fails
+func UpdateInstallationAccessToken(token *models.Token, accessToken string, accessTokenExpiry time.Time) error {
+	now := time.Now()
+	token.AccessToken = accessToken
+	token.AccessTokenExpiry = &accessTokenExpiry
+	token.AccessTokenIssuedAt = now
+	token.UpdatedAt = now
+	// Updating from the struct, not a map, so the access token goes through the encrypting serializer
+	return db.DB.Model(token).
+		Select("access_token", "access_token_expiry", "access_token_issued_at", "updated_at").
+		Updates(token).Error
+}
@@ -0,0 +1,363 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"gorm.io/gorm"
+)
+
+// TrashEntry is a top-level item in a user's trash, i.e. a file or folder that was deleted directly
+// rather than along with a parent folder
+type TrashEntry struct {
+	Kind         int
+	ID           string
+	Name         string
+	Size         int64
+	OriginalPath *string
+	DeletedAt    time.Time
+}
+
+// ExpiredTrashRoot identifies a top-level trashed item whose retention period has elapsed
+type ExpiredTrashRoot struct {
+	ID     string
+	UserID string
+}
+
+// TrashFile moves a file owned by the user to the trash
+//
+// Parameters:
+//   - fileID: The ID of the file to trash
+//   - userID: The ID of the user who owns the file
+//
+// Returns:
+//   - gorm.ErrRecordNotFound if the file does not exist, is already trashed or belongs to another user
+//   - An error if the database operation fails
+func TrashFile(fileID string, userID string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+		var file models.File
+		if err := tx.Where("id = ? AND user_id = ?", fileID, userID).First(&file).Error; err != nil {
+			return err
+		}
+		return trashFile(tx, &file)
+	})
+}
+
+// TrashFolder moves a folder owned by the user, and everything below it, to the trash
+//
+// Parameters:
+//   - folderID: The ID of the folder to trash
+//   - userID: The ID of the user who owns the folder
+//
+// Returns:
+//   - gorm.ErrRecordNotFound if the folder does not exist, is already trashed or belongs to another user
+//   - An error if the database operation fails
+func TrashFolder(folderID string, userID string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+		var folder models.Folder
+		if err := tx.Where("id = ? AND user_id = ?", folderID, userID).First(&folder).Error; err != nil {
+			return err
+		}
+
+		originalPath, err := folderPath(tx, &folder.ID)
+		if err != nil {
+			return err
+		}
+
+		subtree, err := liveSubtreeFolderIDs(tx, folder.ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Folder{}).Where("id IN ?", subtree).Updates(map[string]interface{}{
+			"deleted_at":    now,
+			"trash_root_id": folder.ID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Folder{}).Unscoped().Where("id = ?", folder.ID).
+			Update("original_path", originalPath).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.File{}).Where("folder_id IN ?", subtree).Updates(map[string]interface{}{
+			"deleted_at":    now,
+			"trash_root_id": folder.ID,
+		}).Error
+	})
+}
+
+// ListTrash returns the top-level items in a user's trash, most recently deleted first
+//
+// Parameters:
+//   - userID: The ID of the user whose trash should be listed
+//
+// Returns:
+//   - A slice of TrashEntry rows
+//   - An error if the database operation fails
+func ListTrash(userID string) ([]TrashEntry, error) {
+	var entries []TrashEntry
+	err := db.DB.Raw(`SELECT kind, id, name, size, original_path, deleted_at FROM (
+		SELECT ? AS kind, id, name, 0::bigint AS size, original_path, deleted_at FROM folders
+		WHERE user_id = ? AND deleted_at IS NOT NULL AND trash_root_id = id
+		UNION ALL
+		SELECT ? AS kind, id, name, size, original_path, deleted_at FROM files
+		WHERE user_id = ? AND deleted_at IS NOT NULL AND trash_root_id = id
+	) entries ORDER BY deleted_at DESC, id`, EntryKindFolder, userID, EntryKindFile, userID).Scan(&entries).Error
+	return entries, err
+}
+
+// RestoreTrashItem restores a top-level trashed file or folder, together with everything that was
+// trashed along with it. The item goes back to its original folder if that folder is still live,
+// otherwise to the user's root.
+//
+// Parameters:
+//   - itemID: The ID of the trashed file or folder
+//   - userID: The ID of the user who owns the item
+//   - conflictPolicy: How to handle a name already taken at the restore location
+//
+// Returns:
+//   - The kind of the restored item (EntryKindFolder or EntryKindFile)
+//   - gorm.ErrRecordNotFound if no such top-level trashed item exists for the user
+//   - ErrNameConflict or ErrInvalidConflictPolicy if the item cannot be restored under its name
+func RestoreTrashItem(itemID string, userID string, conflictPolicy string) (int, error) {
+	kind := EntryKindFolder
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+
+		var name string
+		var parentID *string
+		var folder models.Folder
+		var file models.File
+		err := tx.Unscoped().Where("id = ? AND user_id = ? AND trash_root_id = id", itemID, userID).First(&folder).Error
+		switch {
+		case err == nil:
+			name, parentID = folder.Name, folder.ParentFolderID
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Unscoped().Where("id = ? AND user_id = ? AND trash_root_id = id", itemID, userID).First(&file).Error; err != nil {
+				return err
+			}
+			kind = EntryKindFile
+			name, parentID = file.Name, file.FolderID
+		default:
+			return err
+		}
+
+		// Fall back to the root when the original folder has itself been trashed
+		if parentID != nil {
+			if err := ensureFolderOwned(tx, *parentID, userID); err != nil {
+				if !errors.Is(err, ErrDestinationNotFound) {
+					return err
+				}
+				parentID = nil
+			}
+		}
+
+		resolution, err := resolveNameConflict(tx, userID, parentID, name, conflictPolicy, kind == EntryKindFile, itemID)
+		if err != nil {
+			return err
+		}
+		if resolution.replaceFileID != nil {
+			if err := trashFileByID(tx, *resolution.replaceFileID); err != nil {
+				return err
+			}
+		}
+
+		restored := map[string]interface{}{
+			"deleted_at":    nil,
+			"trash_root_id": nil,
+			"original_path": nil,
+		}
+		if err := tx.Model(&models.Folder{}).Unscoped().Where("trash_root_id = ?", itemID).Updates(restored).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.File{}).Unscoped().Where("trash_root_id = ?", itemID).Updates(restored).Error; err != nil {
+			return err
+		}
+
+		if kind == EntryKindFolder {
+			return tx.Model(&models.Folder{}).Where("id = ?", itemID).Updates(map[string]interface{}{
+				"name":             resolution.name,
+				"parent_folder_id": parentID,
+			}).Error
+		}
+		return tx.Model(&models.File{}).Where("id = ?", itemID).Updates(map[string]interface{}{
+			"name":      resolution.name,
+			"folder_id": parentID,
+		}).Error
+	})
+	return kind, err
+}
+
+// FindExpiredTrashRoots returns top-level trashed items that were deleted before the cutoff
+//
+// Parameters:
+//   - cutoff: Items trashed before this time are returned
+//   - limit: The maximum number of items to return
+//
+// Returns:
+//   - A slice of ExpiredTrashRoot rows, oldest first
+//   - An error if the database operation fails
+func FindExpiredTrashRoots(cutoff time.Time, limit int) ([]ExpiredTrashRoot, error) {
+	var roots []ExpiredTrashRoot
+	err := db.DB.Raw(`SELECT id, user_id FROM (
+		SELECT id, user_id, deleted_at FROM folders WHERE deleted_at < ? AND trash_root_id = id
+		UNION ALL
+		SELECT id, user_id, deleted_at FROM files WHERE deleted_at < ? AND trash_root_id = id
+	) roots ORDER BY deleted_at LIMIT ?`, cutoff, cutoff, limit).Scan(&roots).Error
+	return roots, err
+}
+
+// PurgeTrashRoot permanently deletes a trashed item and everything trashed along with it,
+// and releases the storage its pushed chunks occupied in their repositories.
+// The item is purged under the owner's tree lock, after checking that it is still in the trash
+// and expired, so a restore, copy or deduplicated upload can't start using its content in between.
+// The blobs and thumbnails that are no longer referenced are returned; the caller removes them
+// from S3 and GitHub once this has returned, so no stored content disappears while a chunk still
+// points at it.
+//
+// Parameters:
+//   - trashRootID: The ID of the top-level trashed item
+//   - userID: The ID of the user who owns the item
+//   - cutoff: The item is only purged if it was trashed before this time
+//
+// Returns:
+//   - The blobs and thumbnails that are no longer referenced
+//   - gorm.ErrRecordNotFound if the item is no longer an expired trash root, e.g. because it was restored
+//   - An error if the database operation fails
+func PurgeTrashRoot(trashRootID string, userID string, cutoff time.Time) (*ReleasedBlobs, error) {
+	var released *ReleasedBlobs
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTree(tx, userID); err != nil {
+			return err
+		}
+		var expired bool
+		if err := tx.Raw(`SELECT EXISTS (
+				SELECT 1 FROM folders WHERE id = ? AND user_id = ? AND trash_root_id = id AND deleted_at < ?
+				UNION ALL
+				SELECT 1 FROM files WHERE id = ? AND user_id = ? AND trash_root_id = id AND deleted_at < ?
+			)`, trashRootID, userID, cutoff, trashRootID, userID, cutoff).Scan(&expired).Error; err != nil {
+			return err
+		}
+		if !expired {
+			return gorm.ErrRecordNotFound
+		}
+
+		files := tx.Unscoped().Model(&models.File{}).Select("id").Where("trash_root_id = ?", trashRootID)
+		folders := tx.Unscoped().Model(&models.Folder{}).Select("id").Where("trash_root_id = ?", trashRootID)
+		chunkIDs := tx.Model(&models.Chunk{}).Select("id").Where("file_id IN (?)", files)
+
+		var err error
+		if released, err = findReleasedBlobs(tx, chunkIDs); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.FileVersion{}).Where("thumbnail_key IS NOT NULL AND file_id IN (?)", files).
+			Pluck("thumbnail_key", &released.Thumbnails).Error; err != nil {
+			return err
+		}
+
+		if err := deleteChunks(tx, chunkIDs); err != nil {
+			return err
+		}
+		if err := tx.Where("file_id IN (?)", files).Delete(&models.FileVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_id IN (?)", files).Delete(&models.FileTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_id IN (?)", files).Delete(&models.FileMetadata{}).Error; err != nil {
+			return err
+		}
+
+		// Items trashed separately from inside a purged folder lose their original parent
+		if err := tx.Unscoped().Model(&models.File{}).
+			Where("folder_id IN (?) AND (trash_root_id IS NULL OR trash_root_id <> ?)", folders, trashRootID).
+			Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Folder{}).
+			Where("parent_folder_id IN (?) AND (trash_root_id IS NULL OR trash_root_id <> ?)", folders, trashRootID).
+			Update("parent_folder_id", nil).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("trash_root_id = ?", trashRootID).Delete(&models.File{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("trash_root_id = ?", trashRootID).Delete(&models.Folder{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// trashFile soft-deletes a live file inside a transaction, recording where it lived
+func trashFile(tx *gorm.DB, file *models.File) error {
+	parentPath, err := folderPath(tx, file.FolderID)
+	if err != nil {
+		return err
+	}
+	originalPath := joinPath(parentPath, file.Name)
+
+	return tx.Model(&models.File{}).Where("id = ?", file.ID).Updates(map[string]interface{}{
+		"deleted_at":    time.Now(),
+		"trash_root_id": file.ID,
+		"original_path": originalPath,
+	}).Error
+}
+
+// trashFileByID soft-deletes the live file with the given ID inside a transaction.
+// Files replaced by the overwrite conflict policy go through here so they stay recoverable.
+func trashFileByID(tx *gorm.DB, fileID string) error {
+	var file models.File
+	if err := tx.Where("id = ?", fileID).First(&file).Error; err != nil {
+		return err
+	}
+	return trashFile(tx, &file)
+}
+
+// liveSubtreeFolderIDs returns the ID of a folder and of every live folder below it
+func liveSubtreeFolderIDs(tx *gorm.DB, folderID string) ([]string, error) {
+	var ids []string
+	err := tx.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM folders WHERE id = ?
+		UNION
+		SELECT f.id FROM folders f JOIN subtree s ON f.parent_folder_id = s.id WHERE f.deleted_at IS NULL
+	) SELECT id FROM subtree`, folderID).Scan(&ids).Error
+	return ids, err
+}
+
+// folderPath returns the slash-separated path of a folder from the user's root, e.g. "/photos/2024".
+// A nil folderID is the root and yields "/".
+func folderPath(tx *gorm.DB, folderID *string) (string, error) {
+	if folderID == nil {
+		return "/", nil
+	}
+	var path string
+	err := tx.Raw(`WITH RECURSIVE ancestors AS (
+		SELECT id, parent_folder_id, name, 0 AS depth FROM folders WHERE id = ?
+		UNION ALL
+		SELECT f.id, f.parent_folder_id, f.name, a.depth + 1 FROM folders f JOIN ancestors a ON f.id = a.parent_folder_id
+	) SELECT '/' || string_agg(name, '/' ORDER BY depth DESC) FROM ancestors`, *folderID).Scan(&path).Error
+	return path, err
+}
+
+// joinPath appends a name to a folder path produced by folderPath
+func joinPath(parentPath, name string) string {
+	if parentPath == "/" {
+		return "/" + name
+	}
+	return parentPath + "/" + name
+}
@@ -0,0 +1,75 @@
+// Package repositories contains database interaction logic for all models
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/db"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindUserKey retrieves the wrapped end-to-end encryption key of a user
+//
+// Parameters:
+//   - userID: The ID of the user
+//
+// Returns:
+//   - A pointer to the UserKey model if found
+//   - gorm.ErrRecordNotFound if the user has not set up end-to-end encryption
+func FindUserKey(userID string) (*models.UserKey, error) {
+	var key models.UserKey
+	if err := db.DB.Where("user_id = ?", userID).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// SaveUserKey stores the wrapped end-to-end encryption key of a user, replacing any previous
+// wrapping. Replacing is how a passphrase change is recorded: the key itself stays the same,
+// so the file keys wrapped with it remain valid.
+//
+// Parameters:
+//   - userID: The ID of the user
+//   - wrappedKey: The key wrapped by the client
+//   - algorithm: The wrapping algorithm
+//   - kdf: The key derivation function used for the wrapping key
+//   - kdfParams: The parameters of the key derivation function
+//
+// Returns:
+//   - A pointer to the saved UserKey model
+//   - An error if the database operation fails
+func SaveUserKey(userID string, wrappedKey string, algorithm string, kdf string, kdfParams string) (*models.UserKey, error) {
+	var key models.UserKey
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&key).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			key = models.UserKey{
+				ID:        uuid.New().String(),
+				UserID:    userID,
+				Version:   1,
+				CreatedAt: now,
+			}
+		case err != nil:
+			return err
+		default:
+			key.Version++
+		}
+
+		key.WrappedKey = wrappedKey
+		key.Algorithm = algorithm
+		key.KDF = kdf
+		key.KDFParams = kdfParams
+		key.UpdatedAt = now
+		return tx.Save(&key).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -1,27 +1,20 @@
 package auth
 
 import (
-	"sync"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/AnshJain-Shwalia/DataHub/backend/config"
 	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
 	userservice "github.com/AnshJain-Shwalia/DataHub/backend/services/user"
-	"github.com/AnshJain-Shwalia/DataHub/backend/util"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// stateRepo is the singleton instance for managing OAuth2 state tokens
-var stateRepo = &StateRepo{
-	tokens: make(map[string]struct{}),
-}
-
-// StateRepo provides thread-safe storage for OAuth2 state tokens
-type StateRepo struct {
-	mu     sync.RWMutex        // Protects tokens map
-	tokens map[string]struct{} // Active state tokens as map keys
-}
-
 // AuthService handles core authentication operations
 type AuthService struct {
 	userService *userservice.UserService
@@ -34,84 +27,173 @@ func NewAuthService() *AuthService {
 	}
 }
 
-// GenerateJWT generates a JWT token for the given user
-// This method extracts the JWT generation logic from the handler
-func (s *AuthService) GenerateJWT(user *models.User) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour * 7)
+// ClientInfo describes the client a session is used from
+type ClientInfo struct {
+	Device    string // the User-Agent header
+	IPAddress string
+}
+
+// SessionTokens is a short-lived access JWT together with the refresh token that renews it
+type SessionTokens struct {
+	AccessToken     string
+	AccessExpiresAt time.Time
+	RefreshToken    string
+}
+
+// RefreshRequest represents the request structure for renewing an access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshResponse represents the response structure after renewing an access token
+type RefreshResponse struct {
+	Success      bool      `json:"success"`
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+}
+
+// GenerateJWT generates a short-lived access JWT for the given user and session
+// The token expires after the configured access token TTL and is renewed with a refresh token.
+// Its jti claim is the session ID, which RequireJWT checks so revoked sessions are rejected.
+func (s *AuthService) GenerateJWT(user *models.User, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(time.Duration(config.LoadConfig().AccessTokenTTLMinutes) * time.Minute)
 
 	claims := jwt.MapClaims{
 		"id":    user.ID,
 		"email": user.Email,
+		"jti":   sessionID,
+		"iat":   now.Unix(),
 		"exp":   expirationTime.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(config.LoadConfig().JWTSecret))
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
-	return tokenString, nil
+	return tokenString, expirationTime, nil
 }
 
-// GetTokenByUserID finds a user by ID and generates a JWT token
-// This method extracts the logic from GetSigninTokenByIDHandler
-func (s *AuthService) GetTokenByUserID(userID string) (string, *models.User, error) {
-	// Find user by ID
-	user, err := s.userService.FindByID(userID)
+// IssueSessionTokens signs a user in: it starts a new session for the client and generates
+// an access JWT and the session's first refresh token
+func (s *AuthService) IssueSessionTokens(user *models.User, client ClientInfo) (*SessionTokens, error) {
+	refreshToken, tokenHash, err := generateRefreshToken()
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
-
-	// Generate JWT token for the user
-	tokenString, err := s.GenerateJWT(user)
+	session, err := repositories.CreateSession(user.ID, client.Device, client.IPAddress, tokenHash, refreshTokenExpiry())
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	return tokenString, user, nil
+	accessToken, expiresAt, err := s.GenerateJWT(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionTokens{
+		AccessToken:     accessToken,
+		AccessExpiresAt: expiresAt,
+		RefreshToken:    refreshToken,
+	}, nil
 }
 
-// OAuth State Management Functions (merged from oauth package)
+// RefreshSession exchanges a refresh token for a new access JWT and a new refresh token.
+// Refresh tokens are single-use: presenting one that was already exchanged revokes every
+// token of the session, so a leaked token cannot keep a session alive.
+func (s *AuthService) RefreshSession(request *RefreshRequest, client ClientInfo) (*RefreshResponse, error) {
+	refreshToken, tokenHash, err := generateRefreshToken()
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to generate refresh token",
+			Code:    "REFRESH_FAILED",
+			Details: err.Error(),
+		}
+	}
 
-// isValidState checks if a state token exists without consuming it (read-only)
-func isValidState(state string) bool {
-	stateRepo.mu.RLock()
-	defer stateRepo.mu.RUnlock()
-	_, exists := stateRepo.tokens[state]
-	return exists
-}
+	replacement, err := repositories.RotateRefreshToken(hashRefreshToken(request.RefreshToken), tokenHash, refreshTokenExpiry(), client.IPAddress)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRefreshTokenInvalid):
+			return nil, &AuthError{
+				Message: "Invalid or expired refresh token",
+				Code:    "INVALID_REFRESH_TOKEN",
+			}
+		case errors.Is(err, repositories.ErrRefreshTokenReused):
+			return nil, &AuthError{
+				Message: "Refresh token has already been used",
+				Code:    "REFRESH_TOKEN_REUSED",
+				Details: "the session has been revoked",
+			}
+		}
+		return nil, &AuthError{
+			Message: "Failed to refresh session",
+			Code:    "REFRESH_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	user, err := s.userService.FindByID(replacement.UserID)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to refresh session",
+			Code:    "REFRESH_FAILED",
+			Details: err.Error(),
+		}
+	}
+	accessToken, expiresAt, err := s.GenerateJWT(user, replacement.FamilyID)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to generate authentication token",
+			Code:    "JWT_GENERATION_FAILED",
+			Details: err.Error(),
+		}
+	}
 
-// addState stores a new OAuth2 state token for CSRF protection
-func addState(state string) {
-	stateRepo.mu.Lock()
-	defer stateRepo.mu.Unlock()
-	stateRepo.tokens[state] = struct{}{} // Using empty struct{} as value to minimize memory usage
+	return &RefreshResponse{
+		Success:      true,
+		Token:        accessToken,
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, nil
 }
 
-// verifyAndConsumeState validates and removes a state token (prevents replay attacks)
-func verifyAndConsumeState(state string) bool {
-	stateRepo.mu.Lock()
-	defer stateRepo.mu.Unlock()
-	_, exists := stateRepo.tokens[state]
-	if exists {
-		delete(stateRepo.tokens, state) // Remove the state token after verification
+// GetTokenByUserID finds a user by ID and signs them in
+// This method extracts the logic from GetSigninTokenByIDHandler
+func (s *AuthService) GetTokenByUserID(userID string, client ClientInfo) (*SessionTokens, *models.User, error) {
+	// Find user by ID
+	user, err := s.userService.FindByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Generate the access and refresh tokens for the user
+	tokens, err := s.IssueSessionTokens(user, client)
+	if err != nil {
+		return nil, nil, err
 	}
-	return exists
+
+	return tokens, user, nil
 }
 
-// removeState deletes a state token (for cleanup of expired/invalid states)
-func removeState(state string) {
-	stateRepo.mu.Lock()
-	defer stateRepo.mu.Unlock()
-	delete(stateRepo.tokens, state)
+// refreshTokenExpiry returns when a refresh token issued now stops being accepted
+func refreshTokenExpiry() time.Time {
+	return time.Now().Add(time.Duration(config.LoadConfig().RefreshTokenTTLDays) * 24 * time.Hour)
 }
 
-// generateAndAddState creates a secure random state token, stores it, and returns it.
-func generateAndAddState() (string, error) {
-	state, err := util.GenerateRandomState()
-	if err != nil {
-		return "", err
+// generateRefreshToken creates a random opaque refresh token and returns it with its hash
+func generateRefreshToken() (string, string, error) {
+	b := make([]byte, 32) // 256 bits of entropy
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
 	}
-	addState(state)
-	return state, nil
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+// hashRefreshToken returns the hex-encoded SHA-256 hash under which a refresh token is stored
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
\ No newline at end of file
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	githubservice "github.com/AnshJain-Shwalia/DataHub/backend/services/github"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// AddInstallationRequest represents the request structure for linking a GitHub App installation.
+// GitHub passes all three values to the app's setup URL after the app has been installed, provided
+// the app requests user authorization during installation.
+type AddInstallationRequest struct {
+	InstallationID int64  `json:"installationId" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+	State          string `json:"state" binding:"required"`
+}
+
+// AddInstallationResponse represents the response structure after linking a GitHub App installation
+type AddInstallationResponse struct {
+	Message        string `json:"message"`
+	Success        bool   `json:"success"`
+	Account        string `json:"account"` // the user or organization the app is installed on
+	InstallationID int64  `json:"installationId"`
+}
+
+// appNotConfiguredError is returned by the installation endpoints when the GitHub App settings are missing
+func appNotConfiguredError() error {
+	return &AuthError{
+		Message: "GitHub App storage is not available",
+		Code:    "GITHUB_APP_NOT_CONFIGURED",
+	}
+}
+
+// GenerateAppInstallURL generates the URL where the user installs the GitHub App on their
+// account or organization and chooses the repositories it may use.
+// The state is bound to the user, so only that user can link the resulting installation.
+func (s *GitHubAuthService) GenerateAppInstallURL(userID string) (string, error) {
+	if !githubservice.AppConfigured() {
+		return "", appNotConfiguredError()
+	}
+
+	state, _, err := generateAndAddState(statePurposeAppInstall, userID, "")
+	if err != nil {
+		return "", stateGenerationError(err)
+	}
+
+	query := url.Values{"state": {state}}
+	return fmt.Sprintf("https://github.com/apps/%s/installations/new?%s", url.PathEscape(config.LoadConfig().GitHubAppSlug), query.Encode()), nil
+}
+
+// AddInstallation links a GitHub App installation to an already authenticated user as a storage account.
+// Installation IDs are not secret, so the user's authorization code is used to confirm that the
+// installation is one the user has access to before it is linked.
+//
+// This method performs the following steps in sequence:
+// 1. Verifies the state parameter was generated for this user to install the app
+// 2. Exchanges the authorization code for a user access token of the app
+// 3. Checks that the installation is among the installations the user can access
+// 4. Mints an installation access token with the app's private key
+// 5. Stores the installation as a "GITHUB_APP" token, using the installation account as identifier
+//
+// Parameters:
+//   - userID: The ID of the authenticated user (from JWT token)
+//   - request: The installation ID, authorization code and state from the setup URL
+//
+// Returns:
+//   - *AddInstallationResponse: Contains success status, message and the installation account
+//   - error: Any error that occurred during processing
+func (s *GitHubAuthService) AddInstallation(userID string, request *AddInstallationRequest) (*AddInstallationResponse, error) {
+	if !githubservice.AppConfigured() {
+		return nil, appNotConfiguredError()
+	}
+
+	// Check state BEFORE processing the code
+	if _, err := verifyAndConsumeState(request.State, statePurposeAppInstall, userID); err != nil {
+		return nil, err
+	}
+
+	userToken, err := exchangeGitHubAppCode(request.Code)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to exchange authorization code for tokens",
+			Code:    "TOKEN_EXCHANGE_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	githubService := githubservice.NewGitHubStorageService()
+	installations, err := githubService.GetUserInstallations(userToken.AccessToken)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to retrieve GitHub App installations",
+			Code:    "INSTALLATION_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	var account string
+	for _, installation := range installations {
+		if installation.ID == request.InstallationID {
+			account = installation.Account.Login
+			break
+		}
+	}
+	if account == "" {
+		return nil, &AuthError{
+			Message: "GitHub App installation not found",
+			Code:    "INSTALLATION_NOT_FOUND",
+			Details: "the installation does not exist or you do not have access to it",
+		}
+	}
+
+	accessToken, expiresAt, err := githubService.CreateInstallationToken(request.InstallationID)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to create installation access token",
+			Code:    "INSTALLATION_TOKEN_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	if _, err := s.tokenService.CreateOrUpdateGitHubAppToken(userID, account, request.InstallationID, accessToken, expiresAt); err != nil {
+		return nil, &AuthError{
+			Message: "Failed to store GitHub App installation",
+			Code:    "TOKEN_STORAGE_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	return &AddInstallationResponse{
+		Message:        "GitHub App installation linked successfully",
+		Success:        true,
+		Account:        account,
+		InstallationID: request.InstallationID,
+	}, nil
+}
+
+// exchangeGitHubAppCode exchanges an authorization code issued during app installation for a
+// user access token of the app. The token is only used to check which installations the user can access.
+func exchangeGitHubAppCode(code string) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	envCfg := config.LoadConfig()
+	oauthConfig := &oauth2.Config{
+		ClientID:     envCfg.GitHubAppClientID,
+		ClientSecret: envCfg.GitHubAppClientSecret,
+		Endpoint:     github.Endpoint,
+	}
+	token, err := oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub App code exchange failed: %v", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("received invalid token from GitHub")
+	}
+	return token, nil
+}
@@ -2,15 +2,22 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	evacuationservice "github.com/AnshJain-Shwalia/DataHub/backend/services/evacuation"
+	githubservice "github.com/AnshJain-Shwalia/DataHub/backend/services/github"
 	tokenservice "github.com/AnshJain-Shwalia/DataHub/backend/services/token"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-resty/resty/v2"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/github"
+	"gorm.io/gorm"
 )
 
 // createGitHubOAuthConfig initializes and returns a new OAuth2 configuration for GitHub authentication.
@@ -60,8 +67,9 @@ type AddAccountResponse struct {
 
 // GetAccountsResponse represents the response structure for listing GitHub accounts
 type GetAccountsResponse struct {
-	Success  bool     `json:"success"`
-	Accounts []string `json:"accounts"`
+	Success       bool     `json:"success"`
+	Accounts      []string `json:"accounts"`      // GitHub OAuth accounts
+	Installations []string `json:"installations"` // accounts the GitHub App is installed on
 }
 
 // AddAccount processes the OAuth2 authorization code received from GitHub's OAuth flow
@@ -84,15 +92,13 @@ type GetAccountsResponse struct {
 //   - error: Any error that occurred during processing
 func (s *GitHubAuthService) AddAccount(userID string, request *AddAccountRequest) (*AddAccountResponse, error) {
 	// Check state BEFORE processing the code
-	if !verifyAndConsumeState(request.State) {
-		return nil, &AuthError{
-			Message: "Invalid state parameter",
-			Code:    "INVALID_STATE",
-		}
+	state, err := verifyAndConsumeState(request.State, statePurposeGitHubLink, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Exchange the authorization code for an access token
-	token, err := exchangeGitHubCodeForTokens(request.Code)
+	token, err := exchangeGitHubCodeForTokens(request.Code, state.CodeVerifier)
 	if err != nil {
 		return nil, &AuthError{
 			Message: "Failed to exchange authorization code for tokens",
@@ -133,18 +139,18 @@ func (s *GitHubAuthService) AddAccount(userID string, request *AddAccountRequest
 // This method extracts the complete business logic from GetGitHubAccountsHandler.
 //
 // This method performs the following steps:
-// 1. Retrieves all GitHub tokens associated with the user from the database
-// 2. Returns a list of connected GitHub usernames
+// 1. Retrieves all GitHub OAuth and GitHub App installation tokens of the user from the database
+// 2. Returns the connected GitHub usernames, separating app installations from OAuth accounts
 //
 // Parameters:
 //   - userID: The ID of the authenticated user (from JWT token)
 //
 // Returns:
-//   - *GetAccountsResponse: Contains success status and list of GitHub usernames
+//   - *GetAccountsResponse: Contains success status and lists of GitHub usernames
 //   - error: Any error that occurred during processing
 func (s *GitHubAuthService) GetAccounts(userID string) (*GetAccountsResponse, error) {
-	// Get all GitHub tokens for the user
-	githubTokens, err := s.tokenService.GetGitHubTokensForUser(userID)
+	// Get all GitHub storage tokens for the user
+	githubTokens, err := s.tokenService.GetStorageTokensForUser(userID)
 	if err != nil {
 		return nil, &AuthError{
 			Message: "Failed to retrieve GitHub accounts",
@@ -155,38 +161,211 @@ func (s *GitHubAuthService) GetAccounts(userID string) (*GetAccountsResponse, er
 
 	// Extract GitHub usernames from the tokens
 	var githubUsernames []string
+	var installationAccounts []string
 	for _, token := range githubTokens {
-		if token.AccountIdentifier != nil {
+		if token.AccountIdentifier == nil {
+			continue
+		}
+		if token.Platform == "GITHUB_APP" {
+			installationAccounts = append(installationAccounts, *token.AccountIdentifier)
+		} else {
 			githubUsernames = append(githubUsernames, *token.AccountIdentifier)
 		}
 	}
 
 	// Return the list of GitHub accounts
 	return &GetAccountsResponse{
-		Success:  true,
-		Accounts: githubUsernames,
+		Success:       true,
+		Accounts:      githubUsernames,
+		Installations: installationAccounts,
 	}, nil
 }
 
-// GenerateOAuthURL generates and returns the GitHub OAuth URL with state
-func (s *GitHubAuthService) GenerateOAuthURL() (string, error) {
-	state, err := generateAndAddState()
+// RemoveAccountResponse represents the response structure after unlinking a GitHub account
+type RemoveAccountResponse struct {
+	Success        bool   `json:"success"`
+	GitHubUsername string `json:"githubUsername"`
+	MovedBlobs     int    `json:"movedBlobs"`
+	MovedBytes     int64  `json:"movedBytes"`
+	DeletedRepos   int    `json:"deletedRepos"`
+}
+
+// RemoveAccount unlinks a GitHub OAuth storage account from the authenticated user.
+//
+// This method performs the following steps in sequence:
+// 1. Moves every chunk stored in the account's repositories into the user's other GitHub accounts
+// 2. Refuses, before anything is copied, if those accounts do not have enough free space
+// 3. Deletes the account's token together with the records of its repositories
+// 4. Optionally deletes the repositories on GitHub; failures are only logged, since the account
+//    is already unlinked
+//
+// Parameters:
+//   - userID: The ID of the authenticated user (from JWT token)
+//   - login: The GitHub username of the account to unlink
+//   - deleteRepos: Whether to delete the account's storage repositories on GitHub
+//
+// Returns:
+//   - *RemoveAccountResponse: Contains the amount of data moved and the number of repositories deleted
+//   - error: Any error that occurred during processing
+func (s *GitHubAuthService) RemoveAccount(userID string, login string, deleteRepos bool) (*RemoveAccountResponse, error) {
+	return s.removeStorageAccount(userID, "GITHUB", login, deleteRepos)
+}
+
+// RemoveInstallation unlinks a GitHub App installation from the authenticated user in the same way
+// as RemoveAccount. The app stays installed on GitHub until the user uninstalls it there.
+//
+// Parameters:
+//   - userID: The ID of the authenticated user (from JWT token)
+//   - account: The user or organization the app is installed on
+//   - deleteRepos: Whether to delete the installation's storage repositories on GitHub
+//
+// Returns:
+//   - *RemoveAccountResponse: Contains the amount of data moved and the number of repositories deleted
+//   - error: Any error that occurred during processing
+func (s *GitHubAuthService) RemoveInstallation(userID string, account string, deleteRepos bool) (*RemoveAccountResponse, error) {
+	return s.removeStorageAccount(userID, "GITHUB_APP", account, deleteRepos)
+}
+
+// removeStorageAccount moves the data out of a storage account of the given platform and unlinks it
+// The user's storage accounts are locked throughout, so concurrent unlinks run one after the other
+// and uploads cannot start referencing data in the account while it is being emptied.
+func (s *GitHubAuthService) removeStorageAccount(userID string, platform string, login string, deleteRepos bool) (*RemoveAccountResponse, error) {
+	var response *RemoveAccountResponse
+	err := repositories.WithStorageAccountLock(userID, func() error {
+		var err error
+		response, err = s.unlinkStorageAccount(userID, platform, login, deleteRepos)
+		return err
+	})
+	if err != nil {
+		if authErr, ok := err.(*AuthError); ok {
+			return nil, authErr
+		}
+		return nil, &AuthError{
+			Message: "Failed to remove GitHub account",
+			Code:    "ACCOUNT_REMOVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return response, nil
+}
+
+// unlinkStorageAccount does the work of removeStorageAccount while the user's storage accounts are locked
+func (s *GitHubAuthService) unlinkStorageAccount(userID string, platform string, login string, deleteRepos bool) (*RemoveAccountResponse, error) {
+	token, err := repositories.FindStorageTokenByAccount(userID, platform, login)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &AuthError{
+				Message: "GitHub account not found",
+				Code:    "ACCOUNT_NOT_FOUND",
+			}
+		}
+		return nil, &AuthError{
+			Message: "Failed to retrieve GitHub account",
+			Code:    "ACCOUNT_REMOVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	// GitHub App installations can receive the data as well
+	githubTokens, err := s.tokenService.GetStorageTokensForUser(userID)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to retrieve GitHub accounts",
+			Code:    "ACCOUNT_REMOVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	otherTokens := make([]models.Token, 0, len(githubTokens))
+	for _, other := range githubTokens {
+		if other.ID != token.ID {
+			otherTokens = append(otherTokens, other)
+		}
+	}
+
+	// Move the account's data out before anything is deleted
+	moved, err := evacuationservice.NewEvacuationService().EvacuateAccount(token, otherTokens)
+	if err != nil {
+		if errors.Is(err, evacuationservice.ErrInsufficientStorage) {
+			return nil, &AuthError{
+				Message: "Not enough free storage in your other GitHub accounts to move this account's data",
+				Code:    "INSUFFICIENT_STORAGE",
+				Details: err.Error(),
+			}
+		}
+		return nil, &AuthError{
+			Message: "Failed to move data out of the GitHub account",
+			Code:    "EVACUATION_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	repos, err := repositories.GetReposForToken(token.ID)
 	if err != nil {
-		return "", &AuthError{
-			Message: "Failed to generate OAuth state",
-			Code:    "STATE_GENERATION_FAILED",
+		return nil, &AuthError{
+			Message: "Failed to retrieve storage repositories",
+			Code:    "ACCOUNT_REMOVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	if err := repositories.DeleteStorageAccount(token.ID); err != nil {
+		if errors.Is(err, repositories.ErrStorageAccountNotEmpty) {
+			return nil, &AuthError{
+				Message: "The GitHub account still holds data",
+				Code:    "ACCOUNT_NOT_EMPTY",
+				Details: "data was added to the account while it was being emptied; try again",
+			}
+		}
+		return nil, &AuthError{
+			Message: "Failed to remove GitHub account",
+			Code:    "ACCOUNT_REMOVAL_FAILED",
 			Details: err.Error(),
 		}
 	}
 
-	authURL := generateGitHubOAuthURL(state)
+	deletedRepos := 0
+	if deleteRepos {
+		// Installation tokens expire, so a current one is minted if needed
+		accessToken, err := githubservice.AccessTokenFor(token)
+		if err != nil {
+			log.Printf("Failed to get an access token to delete the repositories of unlinked account %s: %v", login, err)
+			repos = nil
+		}
+		githubService := githubservice.NewGitHubStorageService()
+		for _, repo := range repos {
+			if err := githubService.DeleteRepository(login, repo.Name, accessToken); err != nil {
+				log.Printf("Failed to delete repository %s/%s of unlinked account: %v", login, repo.Name, err)
+				continue
+			}
+			deletedRepos++
+		}
+	}
+
+	return &RemoveAccountResponse{
+		Success:        true,
+		GitHubUsername: login,
+		MovedBlobs:     moved.MovedBlobs,
+		MovedBytes:     moved.MovedBytes,
+		DeletedRepos:   deletedRepos,
+	}, nil
+}
+
+// GenerateOAuthURL generates and returns the GitHub OAuth URL with state
+// The state is bound to the user linking the account, so only that user can complete the flow
+func (s *GitHubAuthService) GenerateOAuthURL(userID string) (string, error) {
+	state, verifier, err := generateAndAddState(statePurposeGitHubLink, userID, "")
+	if err != nil {
+		return "", stateGenerationError(err)
+	}
+
+	authURL := generateGitHubOAuthURL(state, verifier)
 	return authURL, nil
 }
 
 // GitHub OAuth Utility Functions (merged from oauth package)
 
 // exchangeGitHubCodeForTokens exchanges an OAuth2 authorization code for an access token and refresh token.
-func exchangeGitHubCodeForTokens(code string) (*oauth2.Token, error) {
+// The verifier is the PKCE code verifier whose challenge was sent in the auth URL.
+func exchangeGitHubCodeForTokens(code string, verifier string) (*oauth2.Token, error) {
 	// Create a context with timeout (10 seconds)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -195,7 +374,7 @@ func exchangeGitHubCodeForTokens(code string) (*oauth2.Token, error) {
 	oauthConfig := createGitHubOAuthConfig(config.LoadConfig().GithubCallbackURL)
 
 	// Exchange the code for a token
-	token, err := oauthConfig.Exchange(ctx, code)
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 	if err != nil {
 		return nil, fmt.Errorf("GitHub code exchange failed: %v", err)
 	}
@@ -215,9 +394,10 @@ func exchangeGitHubCodeForTokens(code string) (*oauth2.Token, error) {
 }
 
 // generateGitHubOAuthURL creates the URL that users should be redirected to for GitHub OAuth2 authentication.
-func generateGitHubOAuthURL(state string) string {
+// The URL carries the S256 PKCE challenge of the verifier.
+func generateGitHubOAuthURL(state string, verifier string) string {
 	oauthConfig := createGitHubOAuthConfig(config.LoadConfig().GithubCallbackURL)
-	return oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	return oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier))
 }
 
 // getGitHubUserInfo fetches the authenticated user's profile information from GitHub's OAuth2 user endpoint.
@@ -76,9 +76,11 @@ type ProcessAuthCodeRequest struct {
 
 // ProcessAuthCodeResponse represents the response structure after processing auth codes
 type ProcessAuthCodeResponse struct {
-	Message string `json:"message"`
-	Success bool   `json:"success"`
-	Token   string `json:"token"`
+	Message      string    `json:"message"`
+	Success      bool      `json:"success"`
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
 }
 
 // ProcessAuthCode processes the OAuth2 authorization code received from Google's OAuth flow.
@@ -90,24 +92,23 @@ type ProcessAuthCodeResponse struct {
 // 3. Retrieves the user's profile information from Google using the obtained tokens
 // 4. Creates a new user account if the user doesn't already exist in the system
 // 5. Stores or updates the user's Google OAuth tokens in the database
-// 6. Generates a JWT token for authenticated access to the application
+// 6. Issues a short-lived access JWT and a refresh token for the new session
 //
 // Parameters:
 //   - request: The authorization code and state from the OAuth callback
+//   - client: The client signing in, recorded on the new session
 //
 // Returns:
-//   - *ProcessAuthCodeResponse: Contains success status, message, and JWT token
+//   - *ProcessAuthCodeResponse: Contains success status, message, access JWT and refresh token
 //   - error: Any error that occurred during processing
-func (s *GoogleAuthService) ProcessAuthCode(request *ProcessAuthCodeRequest) (*ProcessAuthCodeResponse, error) {
+func (s *GoogleAuthService) ProcessAuthCode(request *ProcessAuthCodeRequest, client ClientInfo) (*ProcessAuthCodeResponse, error) {
 	// Check state BEFORE processing the code
-	if !verifyAndConsumeState(request.State) {
-		return nil, &AuthError{
-			Message: "Invalid state parameter",
-			Code:    "INVALID_STATE",
-		}
+	state, err := verifyAndConsumeState(request.State, statePurposeGoogleLogin, "")
+	if err != nil {
+		return nil, err
 	}
 
-	token, err := exchangeGoogleCodeForTokens(request.Code)
+	token, err := exchangeGoogleCodeForTokens(request.Code, state.CodeVerifier)
 	if err != nil {
 		return nil, &AuthError{
 			Message: "Failed to exchange authorization code for tokens",
@@ -146,8 +147,8 @@ func (s *GoogleAuthService) ProcessAuthCode(request *ProcessAuthCodeRequest) (*P
 		}
 	}
 
-	// Generate a JWT token for authenticated access to the application
-	tokenString, err := s.authService.GenerateJWT(user)
+	// Generate the access JWT and the refresh token that renews it
+	tokens, err := s.authService.IssueSessionTokens(user, client)
 	if err != nil {
 		return nil, &AuthError{
 			Message: "Failed to generate authentication token",
@@ -156,27 +157,26 @@ func (s *GoogleAuthService) ProcessAuthCode(request *ProcessAuthCodeRequest) (*P
 		}
 	}
 
-	// Return success response with JWT token
+	// Return success response with the session tokens
 	return &ProcessAuthCodeResponse{
-		Message: "Authentication successful",
-		Success: true,
-		Token:   tokenString,
+		Message:      "Authentication successful",
+		Success:      true,
+		Token:        tokens.AccessToken,
+		ExpiresAt:    tokens.AccessExpiresAt,
+		RefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
 // GenerateOAuthURL generates and returns the Google OAuth URL with state
-func (s *GoogleAuthService) GenerateOAuthURL() (string, error) {
-	state, err := generateAndAddState()
+// Pending sign-in states are limited per clientIP, since no user is signed in yet
+func (s *GoogleAuthService) GenerateOAuthURL(clientIP string) (string, error) {
+	state, verifier, err := generateAndAddState(statePurposeGoogleLogin, "", clientIP)
 	if err != nil {
-		return "", &AuthError{
-			Message: "Failed to generate OAuth state",
-			Code:    "STATE_GENERATION_FAILED",
-			Details: err.Error(),
-		}
+		return "", stateGenerationError(err)
 	}
 
 	// Use fixed redirect URL from config for security
-	authURL := generateGoogleOAuthURL(state)
+	authURL := generateGoogleOAuthURL(state, verifier)
 	return authURL, nil
 }
 
@@ -233,7 +233,8 @@ func getGoogleUserInfo(token *oauth2.Token) (*GoogleUserInfo, error) {
 }
 
 // exchangeGoogleCodeForTokens exchanges an OAuth2 authorization code for an access token and refresh token.
-func exchangeGoogleCodeForTokens(code string) (*oauth2.Token, error) {
+// The verifier is the PKCE code verifier whose challenge was sent in the auth URL.
+func exchangeGoogleCodeForTokens(code string, verifier string) (*oauth2.Token, error) {
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -242,7 +243,7 @@ func exchangeGoogleCodeForTokens(code string) (*oauth2.Token, error) {
 	oauthConfig := createGoogleOAuthConfig(config.LoadConfig().GoogleCallbackURL)
 
 	// Exchange will handle all the HTTP details and parameter encoding
-	token, err := oauthConfig.Exchange(ctx, code)
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 	if err != nil {
 		return nil, fmt.Errorf("code exchange failed: %v", err)
 	}
@@ -256,7 +257,8 @@ func exchangeGoogleCodeForTokens(code string) (*oauth2.Token, error) {
 }
 
 // generateGoogleOAuthURL creates the URL that users should be redirected to for Google OAuth2 authentication.
-func generateGoogleOAuthURL(state string) string {
+// The URL carries the S256 PKCE challenge of the verifier.
+func generateGoogleOAuthURL(state string, verifier string) string {
 	oauthConfig := createGoogleOAuthConfig(config.LoadConfig().GoogleCallbackURL)
-	return oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	return oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier))
 }
\ No newline at end of file
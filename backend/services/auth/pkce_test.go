@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// TestMain provides the settings config.LoadConfig requires, so the auth URLs can be built
+func TestMain(m *testing.M) {
+	for name, value := range map[string]string{
+		"GOOGLE_CLIENT_ID":      "google-client",
+		"GOOGLE_CLIENT_SECRET":  "google-secret",
+		"GITHUB_CLIENT_ID":      "github-client",
+		"GITHUB_CLIENT_SECRET":  "github-secret",
+		"DATABASE_URL":          "postgres://localhost/datahub_test",
+		"PORT":                  "8080",
+		"JWT_SECRET":            "jwt-secret",
+		"TOKEN_ENCRYPTION_KEY":  base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		"AWS_ACCESS_KEY_ID":     "access-key",
+		"AWS_SECRET_ACCESS_KEY": "secret-key",
+		"AWS_REGION":            "us-east-1",
+		"S3_BUCKET_NAME":        "datahub-test",
+	} {
+		if _, set := os.LookupEnv(name); !set {
+			os.Setenv(name, value)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestOAuthURLCarriesPKCEChallenge(t *testing.T) {
+	generators := map[string]func(state string, verifier string) string{
+		"google": generateGoogleOAuthURL,
+		"github": generateGitHubOAuthURL,
+	}
+	tests := []struct {
+		name          string
+		verifier      string
+		wantChallenge string
+	}{
+		{
+			// Example from RFC 7636, appendix B
+			name:          "RFC 7636 example",
+			verifier:      "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk",
+			wantChallenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		},
+		{name: "generated verifier", verifier: oauth2.GenerateVerifier()},
+	}
+	for provider, generate := range generators {
+		for _, tt := range tests {
+			t.Run(provider+"/"+tt.name, func(t *testing.T) {
+				want := tt.wantChallenge
+				if want == "" {
+					sum := sha256.Sum256([]byte(tt.verifier))
+					want = base64.RawURLEncoding.EncodeToString(sum[:])
+				}
+
+				authURL, err := url.Parse(generate("state-token", tt.verifier))
+				if err != nil {
+					t.Fatalf("auth URL does not parse: %v", err)
+				}
+				query := authURL.Query()
+				if got := query.Get("code_challenge"); got != want {
+					t.Errorf("code_challenge = %q, want %q", got, want)
+				}
+				if got := query.Get("code_challenge_method"); got != "S256" {
+					t.Errorf("code_challenge_method = %q, want S256", got)
+				}
+				if got := query.Get("state"); got != "state-token" {
+					t.Errorf("state = %q, want %q", got, "state-token")
+				}
+				if query.Has("code_verifier") {
+					t.Errorf("auth URL %s leaks the code verifier", authURL)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// OAuth state purposes: each state is only accepted by the flow it was generated for
+const (
+	statePurposeGoogleLogin = "GOOGLE_LOGIN"
+	statePurposeGitHubLink  = "GITHUB_LINK"
+	statePurposeAppInstall  = "GITHUB_APP_INSTALL"
+)
+
+// StateStore keeps OAuth2 state tokens from generating an authorization URL until the
+// callback consumes them. States expire after the configured TTL, each requester may only hold
+// a few pending states, and the oldest states are evicted once the store is full, so abandoned
+// logins cannot grow the store without limit and one client cannot lock everyone else out.
+type StateStore interface {
+	// Add stores a new state, evicting the oldest states if the store is full. It returns
+	// repositories.ErrTooManyOAuthStates when the state's requester has too many pending states.
+	Add(state *models.OAuthState) error
+	// Consume removes a state and returns it, or returns nil if it is unknown or expired.
+	Consume(state string) (*models.OAuthState, error)
+	// DeleteExpired removes expired states and returns how many were removed.
+	DeleteExpired() (int64, error)
+}
+
+var (
+	stateStore     StateStore
+	stateStoreOnce sync.Once
+)
+
+// getStateStore returns the state store selected by the OAUTH_STATE_STORE config
+func getStateStore() StateStore {
+	stateStoreOnce.Do(func() {
+		cfg := config.LoadConfig()
+		store, err := newStateStore(cfg.OAuthStateStore, cfg.OAuthStateMaxPending, cfg.OAuthStateMaxPendingPerClient)
+		if err != nil {
+			log.Fatalf("Failed to create OAuth state store: %v", err)
+		}
+		stateStore = store
+	})
+	return stateStore
+}
+
+// newStateStore creates a state store of the given kind holding at most maxPending states,
+// of which at most maxPerRequester belong to the same requester
+func newStateStore(kind string, maxPending int, maxPerRequester int) (StateStore, error) {
+	switch kind {
+	case "postgres":
+		return &dbStateStore{maxPending: maxPending, maxPerRequester: maxPerRequester}, nil
+	case "memory":
+		return &memoryStateStore{
+			maxPending:      maxPending,
+			maxPerRequester: maxPerRequester,
+			states:          make(map[string]models.OAuthState),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown OAuth state store %q, expected postgres or memory", kind)
+	}
+}
+
+// dbStateStore keeps states in Postgres, so any backend instance can complete a login
+type dbStateStore struct {
+	maxPending      int
+	maxPerRequester int
+}
+
+func (s *dbStateStore) Add(state *models.OAuthState) error {
+	return repositories.CreateOAuthState(state, s.maxPending, s.maxPerRequester)
+}
+
+func (s *dbStateStore) Consume(state string) (*models.OAuthState, error) {
+	consumed, err := repositories.ConsumeOAuthState(state)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return consumed, err
+}
+
+func (s *dbStateStore) DeleteExpired() (int64, error) {
+	return repositories.DeleteExpiredOAuthStates()
+}
+
+// memoryStateStore keeps states in process memory; only suitable for a single backend instance
+type memoryStateStore struct {
+	mu              sync.Mutex
+	maxPending      int
+	maxPerRequester int
+	states          map[string]models.OAuthState
+}
+
+func (s *memoryStateStore) Add(state *models.OAuthState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	pending := 0
+	for _, entry := range s.states {
+		if entry.Requester == state.Requester && entry.ExpiresAt.After(now) {
+			pending++
+		}
+	}
+	if pending >= s.maxPerRequester {
+		return repositories.ErrTooManyOAuthStates
+	}
+	if len(s.states) >= s.maxPending {
+		s.deleteExpiredLocked()
+	}
+	for len(s.states) >= s.maxPending {
+		s.deleteOldestLocked()
+	}
+	s.states[state.State] = *state
+	return nil
+}
+
+func (s *memoryStateStore) Consume(state string) (*models.OAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, exists := s.states[state]
+	if !exists {
+		return nil, nil
+	}
+	delete(s.states, state) // a state is single-use, even when it turns out to be expired
+	if !entry.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (s *memoryStateStore) DeleteExpired() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteExpiredLocked(), nil
+}
+
+// deleteExpiredLocked removes expired states; the caller must hold s.mu
+func (s *memoryStateStore) deleteExpiredLocked() int64 {
+	now := time.Now()
+	var deleted int64
+	for key, entry := range s.states {
+		if !entry.ExpiresAt.After(now) {
+			delete(s.states, key)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// deleteOldestLocked removes the state that expires first; the caller must hold s.mu
+func (s *memoryStateStore) deleteOldestLocked() {
+	oldest := ""
+	var oldestExpiry time.Time
+	for key, entry := range s.states {
+		if oldest == "" || entry.ExpiresAt.Before(oldestExpiry) {
+			oldest = key
+			oldestExpiry = entry.ExpiresAt
+		}
+	}
+	delete(s.states, oldest)
+}
+
+// stateGenerationError wraps a failure to generate an OAuth state in an *AuthError
+func stateGenerationError(err error) *AuthError {
+	if errors.Is(err, repositories.ErrTooManyOAuthStates) {
+		return &AuthError{
+			Message: "Too many pending sign-in attempts, try again later",
+			Code:    "TOO_MANY_PENDING_STATES",
+			Details: err.Error(),
+		}
+	}
+	return &AuthError{
+		Message: "Failed to generate OAuth state",
+		Code:    "STATE_GENERATION_FAILED",
+		Details: err.Error(),
+	}
+}
+
+// generateAndAddState creates a secure random state token and PKCE code verifier for the given
+// purpose, stores them, and returns them. The S256 challenge of the verifier goes into the auth
+// URL, and the verifier itself is sent when exchanging the code.
+// userID is the signed-in user starting the flow, or an empty string for sign-in flows, which are
+// limited by clientIP instead.
+func generateAndAddState(purpose string, userID string, clientIP string) (string, string, error) {
+	state, err := util.GenerateRandomState()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+	now := time.Now()
+	entry := &models.OAuthState{
+		State:        state,
+		Purpose:      purpose,
+		Requester:    "ip:" + clientIP,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(time.Duration(config.LoadConfig().OAuthStateTTLMinutes) * time.Minute),
+		CreatedAt:    now,
+	}
+	if userID != "" {
+		entry.UserID = &userID
+		entry.Requester = "user:" + userID
+	}
+	if err := getStateStore().Add(entry); err != nil {
+		return "", "", err
+	}
+	return state, verifier, nil
+}
+
+// verifyAndConsumeState validates and removes a state token (prevents replay attacks)
+// The state must have been generated for the same purpose and user; a mismatching state is
+// still consumed so it cannot be retried. It returns an *AuthError if the state is unknown,
+// expired, mismatching or cannot be verified.
+func verifyAndConsumeState(state string, purpose string, userID string) (*models.OAuthState, error) {
+	entry, err := getStateStore().Consume(state)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "Failed to verify state parameter",
+			Code:    "STATE_VERIFICATION_FAILED",
+			Details: err.Error(),
+		}
+	}
+	if entry == nil || entry.Purpose != purpose || !stateUserMatches(entry, userID) {
+		return nil, &AuthError{
+			Message: "Invalid state parameter",
+			Code:    "INVALID_STATE",
+		}
+	}
+	return entry, nil
+}
+
+// stateUserMatches reports whether a state was generated for the given user, or for no user
+// when userID is empty
+func stateUserMatches(entry *models.OAuthState, userID string) bool {
+	if entry.UserID == nil {
+		return userID == ""
+	}
+	return *entry.UserID == userID
+}
+
+// StartStateCleanupWorker deletes expired OAuth states in the background at the configured interval
+func StartStateCleanupWorker() {
+	store := getStateStore()
+	interval := time.Duration(config.LoadConfig().OAuthStateCleanupIntervalMinutes) * time.Minute
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			<-ticker.C
+			deleted, err := store.DeleteExpired()
+			if err != nil {
+				log.Printf("OAuth state cleanup failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Deleted %d expired OAuth states", deleted)
+			}
+		}
+	}()
+}
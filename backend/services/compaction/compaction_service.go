@@ -0,0 +1,138 @@
+package compaction
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	githubservice "github.com/AnshJain-Shwalia/DataHub/backend/services/github"
+)
+
+// compactionBatchSize bounds how many branches are compacted per run
+const compactionBatchSize = 10
+
+// CompactionService reclaims repository space held by the Git history of deleted chunks.
+// Deleting a chunk removes it from the branch tip with a new commit, but its blob stays
+// reachable from older commits. Compaction rewrites the branch as a single orphan commit
+// that contains only the chunks still in use, so the old history can be garbage collected.
+type CompactionService struct{}
+
+// NewCompactionService creates a new instance of CompactionService
+func NewCompactionService() *CompactionService {
+	return &CompactionService{}
+}
+
+// CompactBranches compacts the branches with the most reclaimable data that reach the configured threshold.
+// A branch that fails to compact is left as it is and retried on the next run.
+//
+// Returns:
+//   - The number of branches compacted
+//   - An error if the branches to compact cannot be listed
+func (s *CompactionService) CompactBranches() (int, error) {
+	minReclaimable := int64(config.LoadConfig().CompactionMinReclaimableMB) * 1024 * 1024
+	branches, err := repositories.FindBranchesToCompact(minReclaimable, compactionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	compacted := 0
+	for i := range branches {
+		if err := s.CompactBranch(&branches[i]); err != nil {
+			log.Printf("Failed to compact branch %s of repo %s: %v", branches[i].Name, branches[i].RepoID, err)
+			continue
+		}
+		compacted++
+	}
+	return compacted, nil
+}
+
+// CompactBranch rewrites a branch as a fresh orphan commit holding only the paths chunks reference,
+// force-updates the branch to it and recalculates the repository's usage.
+// The branch is locked for the whole rewrite, and every commit to the branch, including blob
+// deletions, takes the same lock, so the head cannot move between reading and replacing it.
+// The branch must have been loaded with its Repo.Token association.
+func (s *CompactionService) CompactBranch(branch *models.Branch) error {
+	location, err := githubservice.LocationForBranch(branch)
+	if err != nil {
+		return err
+	}
+	githubService := githubservice.NewGitHubStorageService()
+
+	var kept []githubservice.TreeEntry
+	var total int
+	err = repositories.WithBranchLock(branch.ID, func() error {
+		_, treeSHA, err := githubService.GetBranchHead(location)
+		if err != nil {
+			return err
+		}
+		files, err := githubService.GetTreeFiles(location, treeSHA)
+		if err != nil {
+			return err
+		}
+		total = len(files)
+		livePaths, err := repositories.GetLiveChunkPaths(branch.ID)
+		if err != nil {
+			return err
+		}
+
+		byPath := make(map[string]githubservice.TreeEntry, len(files))
+		for _, file := range files {
+			byPath[file.Path] = file
+		}
+		keptPaths := make(map[string]bool, len(livePaths))
+		kept = make([]githubservice.TreeEntry, 0, len(livePaths))
+		for _, path := range livePaths {
+			file, ok := byPath[path]
+			if !ok {
+				// Rewriting now would not lose the chunk, but the branch doesn't match the database, so leave it alone
+				return fmt.Errorf("live chunk path %s is missing from the branch", path)
+			}
+			kept = append(kept, file)
+			keptPaths[path] = true
+		}
+
+		commit, err := githubService.CreateOrphanCommit(location, kept, "Compact storage branch")
+		if err != nil {
+			return err
+		}
+
+		// Anything that starts referencing the branch while the commit is built must be in it
+		livePaths, err = repositories.GetLiveChunkPaths(branch.ID)
+		if err != nil {
+			return err
+		}
+		for _, path := range livePaths {
+			if !keptPaths[path] {
+				return fmt.Errorf("chunk path %s was added during compaction", path)
+			}
+		}
+		return githubService.ForceUpdateBranch(location, commit)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Compacted branch %s of repo %s: kept %d of %d files", branch.Name, branch.Repo.Name, len(kept), total)
+	return repositories.CompleteBranchCompaction(branch)
+}
+
+// StartCompactionWorker runs CompactBranches in the background at the configured interval
+func (s *CompactionService) StartCompactionWorker() {
+	interval := time.Duration(config.LoadConfig().CompactionIntervalMinutes) * time.Minute
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			compacted, err := s.CompactBranches()
+			if err != nil {
+				log.Printf("Repository compaction failed: %v", err)
+			} else if compacted > 0 {
+				log.Printf("Compacted %d storage branches", compacted)
+			}
+			<-ticker.C
+		}
+	}()
+}
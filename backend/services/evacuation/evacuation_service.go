@@ -0,0 +1,193 @@
+package evacuation
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	githubservice "github.com/AnshJain-Shwalia/DataHub/backend/services/github"
+)
+
+// ErrInsufficientStorage is returned when the other storage accounts of a user cannot hold the
+// data of the account being evacuated
+var ErrInsufficientStorage = errors.New("not enough free storage in the other linked accounts")
+
+// EvacuationService moves the chunk data stored in one GitHub storage account into the other
+// storage accounts of the same user, so the account can be unlinked without losing files
+type EvacuationService struct{}
+
+// NewEvacuationService creates a new instance of EvacuationService
+func NewEvacuationService() *EvacuationService {
+	return &EvacuationService{}
+}
+
+// EvacuationResult summarises the data moved out of a storage account
+type EvacuationResult struct {
+	MovedBlobs int
+	MovedBytes int64
+}
+
+// pendingBlob is a blob that still has to be moved, together with the branch holding it
+type pendingBlob struct {
+	branch *models.Branch
+	blob   repositories.BranchBlob
+}
+
+// target is a branch that evacuated blobs can be copied into
+type target struct {
+	branch   *models.Branch
+	location *githubservice.BranchLocation
+}
+
+// repoCapacity returns how many bytes of chunk data a storage repository may hold
+func repoCapacity() int64 {
+	return int64(config.LoadConfig().GitHubRepoCapacityMB) * 1024 * 1024
+}
+
+// EvacuateAccount copies every blob stored in the repositories of token into branches of the
+// user's other storage accounts and repoints the chunks referencing it. Blobs keep their Git
+// path, so encrypted blobs stay valid without re-encryption. The free space of the other
+// accounts is checked before anything is copied; ErrInsufficientStorage is returned if it
+// cannot hold all the data. A failure part way leaves the blobs moved so far in their new place.
+// The caller must hold the user's storage account lock (see repositories.WithStorageAccountLock).
+//
+// Parameters:
+//   - token: The storage account to empty
+//   - otherTokens: The user's other storage accounts that receive the data
+//
+// Returns:
+//   - The number of blobs and bytes moved
+//   - An error if the data cannot be moved
+func (s *EvacuationService) EvacuateAccount(token *models.Token, otherTokens []models.Token) (*EvacuationResult, error) {
+	sourceBranches, err := repositories.GetStorageBranches([]string{token.ID})
+	if err != nil {
+		return nil, err
+	}
+	var pending []pendingBlob
+	var needed int64
+	for i := range sourceBranches {
+		blobs, err := repositories.GetBranchBlobs(sourceBranches[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range blobs {
+			pending = append(pending, pendingBlob{branch: &sourceBranches[i], blob: blob})
+			needed += blob.Size
+		}
+	}
+	result := &EvacuationResult{}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	otherTokenIDs := make([]string, 0, len(otherTokens))
+	for _, other := range otherTokens {
+		otherTokenIDs = append(otherTokenIDs, other.ID)
+	}
+	targetBranches, err := repositories.GetStorageBranches(otherTokenIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Free space is tracked per repository, since branches of a repository share its capacity
+	capacity := repoCapacity()
+	free := make(map[string]int64)
+	targets := make([]target, 0, len(targetBranches))
+	for i := range targetBranches {
+		branch := &targetBranches[i]
+		location, err := githubservice.LocationForBranch(branch)
+		if err != nil {
+			log.Printf("Skipping branch %s as an evacuation target: %v", branch.ID, err)
+			continue
+		}
+		if _, seen := free[branch.RepoID]; !seen {
+			free[branch.RepoID] = max(capacity-branch.Repo.UsedBytes, 0)
+		}
+		targets = append(targets, target{branch: branch, location: location})
+	}
+	var available int64
+	for _, bytes := range free {
+		available += bytes
+	}
+	if available < needed {
+		return nil, fmt.Errorf("%w: %d bytes needed, %d bytes free", ErrInsufficientStorage, needed, available)
+	}
+
+	// Place the largest blobs first so they are not left without a repository that fits them
+	sort.Slice(pending, func(i, j int) bool { return pending[i].blob.Size > pending[j].blob.Size })
+
+	githubService := githubservice.NewGitHubStorageService()
+	for _, item := range pending {
+		dest := pickTarget(targets, free, item.blob.Size)
+		if dest == nil {
+			return result, fmt.Errorf("%w: no repository can hold a blob of %d bytes", ErrInsufficientStorage, item.blob.Size)
+		}
+
+		source, err := githubservice.LocationForBranch(item.branch)
+		if err != nil {
+			return result, err
+		}
+		content, err := githubService.GetFileContent(blobLocation(source, item.blob.GitPath))
+		if err != nil {
+			return result, fmt.Errorf("failed to read %s from %s: %w", item.blob.GitPath, source.Repo, err)
+		}
+		// The copy and the chunk records move together under the branch lock, so compaction of the
+		// target never sees the blob without the chunks that reference it
+		var moved int64
+		err = repositories.WithBranchLock(dest.branch.ID, func() error {
+			destination := blobLocation(dest.location, item.blob.GitPath)
+			if err := githubService.CreateFile(destination, content, "Move chunk from unlinked storage account"); err != nil {
+				return fmt.Errorf("failed to write %s to %s: %w", item.blob.GitPath, dest.location.Repo, err)
+			}
+			if moved, err = repositories.MoveBlob(item.branch, dest.branch, item.blob); err != nil {
+				return err
+			}
+			if moved == 0 {
+				// Every chunk of the blob was deleted while it was being copied
+				if err := githubService.DeleteFile(destination, "Remove deleted chunk"); err != nil {
+					log.Printf("Failed to remove orphaned copy of %s from %s: %v", item.blob.GitPath, dest.location.Repo, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+		if moved == 0 {
+			continue
+		}
+		free[dest.branch.RepoID] -= item.blob.Size
+		result.MovedBlobs++
+		result.MovedBytes += item.blob.Size
+	}
+	return result, nil
+}
+
+// pickTarget returns the target whose repository has the most free space, if it can hold size bytes
+func pickTarget(targets []target, free map[string]int64, size int64) *target {
+	var best *target
+	for i := range targets {
+		if best == nil || free[targets[i].branch.RepoID] > free[best.branch.RepoID] {
+			best = &targets[i]
+		}
+	}
+	if best == nil || free[best.branch.RepoID] < size {
+		return nil
+	}
+	return best
+}
+
+// blobLocation builds the location of a file inside a branch
+func blobLocation(branch *githubservice.BranchLocation, path string) *githubservice.BlobLocation {
+	return &githubservice.BlobLocation{
+		Owner:       branch.Owner,
+		Repo:        branch.Repo,
+		Branch:      branch.Branch,
+		Path:        path,
+		AccessToken: branch.AccessToken,
+	}
+}
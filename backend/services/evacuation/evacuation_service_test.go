@@ -0,0 +1,48 @@
+package evacuation
+
+import (
+	"testing"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+)
+
+// targetsInRepos returns one target per repository ID, in order, on branches named a, b, c, ...
+func targetsInRepos(repoIDs ...string) []target {
+	targets := make([]target, 0, len(repoIDs))
+	for i, repoID := range repoIDs {
+		targets = append(targets, target{branch: &models.Branch{ID: string(rune('a' + i)), RepoID: repoID}})
+	}
+	return targets
+}
+
+func TestPickTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    []target
+		free       map[string]int64
+		size       int64
+		wantBranch string // empty when no target fits
+	}{
+		{name: "no targets", targets: nil, free: map[string]int64{}, size: 1},
+		{name: "most free space wins", targets: targetsInRepos("r1", "r2", "r3"), free: map[string]int64{"r1": 100, "r2": 300, "r3": 200}, size: 50, wantBranch: "b"},
+		{name: "exact fit", targets: targetsInRepos("r1", "r2"), free: map[string]int64{"r1": 10, "r2": 64}, size: 64, wantBranch: "b"},
+		{name: "ties go to the first target", targets: targetsInRepos("r1", "r2"), free: map[string]int64{"r1": 100, "r2": 100}, size: 100, wantBranch: "a"},
+		{name: "branches share their repository's space", targets: targetsInRepos("r1", "r1", "r2"), free: map[string]int64{"r1": 500, "r2": 400}, size: 450, wantBranch: "a"},
+		{name: "too large for every repository", targets: targetsInRepos("r1", "r2"), free: map[string]int64{"r1": 100, "r2": 99}, size: 101},
+		{name: "full repositories", targets: targetsInRepos("r1", "r2"), free: map[string]int64{"r1": 0, "r2": 0}, size: 1},
+		{name: "empty blob fits anywhere", targets: targetsInRepos("r1"), free: map[string]int64{"r1": 0}, size: 0, wantBranch: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pickTarget(tt.targets, tt.free, tt.size)
+			switch {
+			case tt.wantBranch == "" && got != nil:
+				t.Errorf("pickTarget() = branch %s, want none", got.branch.ID)
+			case tt.wantBranch != "" && got == nil:
+				t.Errorf("pickTarget() = none, want branch %s", tt.wantBranch)
+			case got != nil && got.branch.ID != tt.wantBranch:
+				t.Errorf("pickTarget() = branch %s, want %s", got.branch.ID, tt.wantBranch)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+)
+
+const (
+	defaultFindLimit = 50
+	maxFindLimit     = 200
+)
+
+// SetTagsRequest represents the request structure for replacing the tags of a file
+type SetTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+// SetMetadataRequest represents the request structure for replacing the key/value metadata of a file
+type SetMetadataRequest struct {
+	Metadata map[string]string `json:"metadata" binding:"required"`
+}
+
+// FindFilesRequest represents the filters accepted when querying files by their attributes.
+// Files must carry every tag and every key/value pair given.
+type FindFilesRequest struct {
+	Tags     []string
+	Metadata map[string]string
+	Limit    int
+	Offset   int
+}
+
+// FindFilesResponse represents one page of files matched by their attributes
+type FindFilesResponse struct {
+	Success    bool           `json:"success"`
+	Files      []FileResponse `json:"files"`
+	NextOffset *int           `json:"nextOffset"`
+}
+
+// SetTags replaces the tags of a file owned by the user
+func (s *FileService) SetTags(userID, fileID string, request *SetTagsRequest) (*FileResponse, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := util.NormalizeTags(request.Tags)
+	if err != nil {
+		return nil, invalidAttributes(err.Error())
+	}
+	if len(tags) > util.MaxTagsPerFile {
+		return nil, invalidAttributes(fmt.Sprintf("a file can have at most %d tags", util.MaxTagsPerFile))
+	}
+
+	if err := repositories.SetFileTags(file.ID, userID, tags); err != nil {
+		return nil, &FileError{
+			Message: "Failed to update file tags",
+			Code:    "FILE_UPDATE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return s.GetFile(userID, file.ID)
+}
+
+// SetMetadata replaces the key/value metadata of a file owned by the user
+func (s *FileService) SetMetadata(userID, fileID string, request *SetMetadataRequest) (*FileResponse, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.Metadata) > util.MaxMetadataPerFile {
+		return nil, invalidAttributes(fmt.Sprintf("a file can have at most %d metadata entries", util.MaxMetadataPerFile))
+	}
+	for key, value := range request.Metadata {
+		if err := util.ValidateMetadataKey(key); err != nil {
+			return nil, invalidAttributes(err.Error())
+		}
+		if len(value) > util.MaxMetadataValueLength {
+			return nil, invalidAttributes(fmt.Sprintf("metadata value of %q must be at most %d bytes", key, util.MaxMetadataValueLength))
+		}
+	}
+
+	if err := repositories.SetFileMetadata(file.ID, userID, request.Metadata); err != nil {
+		return nil, &FileError{
+			Message: "Failed to update file metadata",
+			Code:    "FILE_UPDATE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return s.GetFile(userID, file.ID)
+}
+
+// FindFiles returns a page of the user's files that carry the requested tags and metadata,
+// most recently created first
+func (s *FileService) FindFiles(userID string, request *FindFilesRequest) (*FindFilesResponse, error) {
+	tags, err := util.NormalizeTags(request.Tags)
+	if err != nil {
+		return nil, invalidAttributes(err.Error())
+	}
+	if len(tags) == 0 && len(request.Metadata) == 0 {
+		return nil, invalidAttributes("at least one tag or metadata filter is required")
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultFindLimit
+	}
+	if limit > maxFindLimit {
+		limit = maxFindLimit
+	}
+	offset := max(request.Offset, 0)
+
+	// Fetch one extra row to find out whether another page exists
+	files, err := repositories.FindFilesByAttributes(userID, tags, request.Metadata, limit+1, offset)
+	if err != nil {
+		return nil, &FileError{
+			Message: "Failed to retrieve files",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	response := &FindFilesResponse{
+		Success: true,
+		Files:   make([]FileResponse, 0, len(files)),
+	}
+	if len(files) > limit {
+		files = files[:limit]
+		next := offset + limit
+		response.NextOffset = &next
+	}
+	for i := range files {
+		response.Files = append(response.Files, *NewFileResponse(&files[i]))
+	}
+	return response, nil
+}
+
+// invalidAttributes reports tags or metadata that cannot be stored or queried
+func invalidAttributes(details string) error {
+	return &FileError{
+		Message: "Invalid file attributes",
+		Code:    "INVALID_ATTRIBUTES",
+		Details: details,
+	}
+}
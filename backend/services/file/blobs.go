@@ -0,0 +1,46 @@
+package file
+
+import (
+	"errors"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	githubservice "github.com/AnshJain-Shwalia/DataHub/backend/services/github"
+	s3service "github.com/AnshJain-Shwalia/DataHub/backend/services/s3"
+)
+
+// DeleteReleasedBlobs removes blobs that no chunk references any more from GitHub and the S3
+// buffer, together with the thumbnails of deleted versions. Every blob is attempted even when
+// an earlier one fails, and blobs that are already gone are not an error. A GitHub blob left
+// behind is no longer a live chunk path, so the next compaction of its branch drops it.
+func DeleteReleasedBlobs(released *repositories.ReleasedBlobs) error {
+	var errs []error
+	githubService := githubservice.NewGitHubStorageService()
+	for i := range released.PushedChunks {
+		chunk := &released.PushedChunks[i]
+		location, err := githubservice.LocationForChunk(chunk)
+		if err == nil {
+			// Deleting a blob commits to its branch, so it must not interleave with a compaction
+			err = repositories.WithBranchLock(chunk.Branch.ID, func() error {
+				return githubService.DeleteFile(location, "Delete chunk "+chunk.ID)
+			})
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	keys := append(append([]string{}, released.S3Keys...), released.Thumbnails...)
+	if len(keys) == 0 {
+		return errors.Join(errs...)
+	}
+	s3Service, err := s3service.NewS3Service()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, key := range keys {
+		if err := s3Service.DeleteObject(key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,216 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	s3service "github.com/AnshJain-Shwalia/DataHub/backend/services/s3"
+)
+
+const (
+	// sniffLength is the number of leading bytes http.DetectContentType looks at
+	sniffLength = 512
+	// thumbnailMaxDimension is the longest side of a generated thumbnail in pixels
+	thumbnailMaxDimension = 256
+	// thumbnailMaxSourceBytes bounds the size of images that get a thumbnail
+	thumbnailMaxSourceBytes = 25 * 1024 * 1024
+	// thumbnailMaxSourcePixels guards against decompression bombs: small files that decode to huge images
+	thumbnailMaxSourcePixels = 40_000_000
+	thumbnailJPEGQuality     = 80
+	// thumbnailMaxConcurrent bounds how many thumbnails are rendered at once, since each one holds
+	// the whole source image and its decoded pixels in memory
+	thumbnailMaxConcurrent = 2
+)
+
+// thumbnailSlots holds one entry per thumbnail that is being rendered
+var thumbnailSlots = make(chan struct{}, thumbnailMaxConcurrent)
+
+// thumbnailContentTypes are the image types the standard library can decode
+var thumbnailContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// detectContentType sniffs the content type of a file from its first bytes. When the data is not
+// recognised the file extension is used instead, falling back to application/octet-stream.
+func detectContentType(name string, head []byte) string {
+	if detected := http.DetectContentType(head); detected != "application/octet-stream" {
+		return detected
+	}
+	return contentTypeByExtension(name)
+}
+
+// contentTypeByExtension derives a content type from a file name, falling back to application/octet-stream
+func contentTypeByExtension(name string) string {
+	if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); byExtension != "" {
+		return byExtension
+	}
+	return "application/octet-stream"
+}
+
+// sniffContentType reads the start of the first chunk of a buffered version and detects its content type.
+// When the first chunk was deduplicated and is not in the buffer, only the file extension is used.
+// Nil is returned for empty files.
+func sniffContentType(s3Service *s3service.S3Service, name string, chunks []models.Chunk) (*string, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	if chunks[0].S3Path == nil {
+		contentType := contentTypeByExtension(name)
+		return &contentType, nil
+	}
+	content, err := s3Service.GetObject(*chunks[0].S3Path)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	head, err := io.ReadAll(io.LimitReader(content, sniffLength))
+	if err != nil {
+		return nil, err
+	}
+	contentType := detectContentType(name, head)
+	return &contentType, nil
+}
+
+// canThumbnail reports whether a thumbnail should be generated for a version
+func canThumbnail(version *models.FileVersion) bool {
+	return version.ContentType != nil && thumbnailContentTypes[*version.ContentType] &&
+		version.Size > 0 && version.Size <= thumbnailMaxSourceBytes
+}
+
+// queueThumbnail renders the thumbnail of a version in the background. When thumbnailMaxConcurrent
+// thumbnails are already being rendered the work is dropped and the version has no thumbnail.
+func queueThumbnail(s3Service *s3service.S3Service, userID string, version *models.FileVersion, chunks []models.Chunk) {
+	select {
+	case thumbnailSlots <- struct{}{}:
+	default:
+		log.Printf("Skipping thumbnail for version %s: too many thumbnails are being rendered", version.ID)
+		return
+	}
+	go func() {
+		defer func() { <-thumbnailSlots }()
+		createThumbnail(s3Service, userID, version, chunks)
+	}()
+}
+
+// createThumbnail reassembles an image version from its buffered chunks, renders a thumbnail
+// and stores it next to the chunks in the S3 buffer. It runs in the background through
+// queueThumbnail; failures only mean the file has no thumbnail, so they are logged.
+func createThumbnail(s3Service *s3service.S3Service, userID string, version *models.FileVersion, chunks []models.Chunk) {
+	var source bytes.Buffer
+	source.Grow(int(version.Size))
+	for _, chunk := range chunks {
+		if chunk.S3Path == nil {
+			log.Printf("Skipping thumbnail for version %s: chunk %s is not buffered", version.ID, chunk.ID)
+			return
+		}
+		content, err := s3Service.GetObject(*chunk.S3Path)
+		if err != nil {
+			log.Printf("Failed to read chunk %s for thumbnail: %v", chunk.ID, err)
+			return
+		}
+		_, err = io.Copy(&source, content)
+		content.Close()
+		if err != nil {
+			log.Printf("Failed to read chunk %s for thumbnail: %v", chunk.ID, err)
+			return
+		}
+	}
+
+	thumbnail, err := renderThumbnail(source.Bytes())
+	if err != nil {
+		log.Printf("Failed to render thumbnail for version %s: %v", version.ID, err)
+		return
+	}
+
+	key := s3service.ThumbnailKey(userID, version.FileID, version.ID)
+	if err := s3Service.PutObject(key, thumbnail, "image/jpeg"); err != nil {
+		log.Printf("Failed to store thumbnail for version %s: %v", version.ID, err)
+		return
+	}
+	if err := repositories.SetVersionThumbnail(version.ID, key); err != nil {
+		log.Printf("Failed to record thumbnail for version %s: %v", version.ID, err)
+	}
+}
+
+// renderThumbnail decodes an image and encodes a JPEG that fits within thumbnailMaxDimension
+// on both sides. Transparent areas are flattened onto white.
+func renderThumbnail(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > thumbnailMaxSourcePixels {
+		return nil, fmt.Errorf("image dimensions %dx%d are not supported", config.Width, config.Height)
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, scaleDown(source, thumbnailMaxDimension), &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// scaleDown resizes an image so that neither side exceeds maxDimension, keeping the aspect ratio.
+// Every destination pixel is the average of the source pixels it covers (box filter), composited
+// onto a white background. Images that already fit are only flattened.
+func scaleDown(source image.Image, maxDimension int) *image.RGBA {
+	bounds := source.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := srcWidth, srcHeight
+	if srcWidth > maxDimension || srcHeight > maxDimension {
+		if srcWidth >= srcHeight {
+			dstWidth, dstHeight = maxDimension, max(1, srcHeight*maxDimension/srcWidth)
+		} else {
+			dstWidth, dstHeight = max(1, srcWidth*maxDimension/srcHeight), maxDimension
+		}
+	}
+
+	destination := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/dstHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcHeight/dstHeight)
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*srcWidth/dstWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcWidth/dstWidth)
+
+			// Channels are premultiplied by alpha, so adding the missing alpha as white flattens the pixel
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := source.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			white := count*0xffff - a
+			destination.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + white) / count >> 8),
+				G: uint8((g + white) / count >> 8),
+				B: uint8((b + white) / count >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return destination
+}
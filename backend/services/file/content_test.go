@@ -0,0 +1,138 @@
+package file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// uniformImage returns an image of the given bounds filled with one color
+func uniformImage(bounds image.Rectangle, c color.Color) image.Image {
+	img := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// pngHeader returns the signature and IHDR chunk of a PNG with the given dimensions, which is
+// all image.DecodeConfig reads
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	ihdr[12] = 8 // bit depth
+	ihdr[13] = 6 // RGBA
+
+	var header bytes.Buffer
+	header.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&header, binary.BigEndian, uint32(len(ihdr)-4))
+	header.Write(ihdr)
+	binary.Write(&header, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return header.Bytes()
+}
+
+func TestScaleDownDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		bounds     image.Rectangle
+		max        int
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "fits", bounds: image.Rect(0, 0, 100, 50), max: 256, wantWidth: 100, wantHeight: 50},
+		{name: "exactly the limit", bounds: image.Rect(0, 0, 256, 256), max: 256, wantWidth: 256, wantHeight: 256},
+		{name: "landscape", bounds: image.Rect(0, 0, 1024, 512), max: 256, wantWidth: 256, wantHeight: 128},
+		{name: "portrait", bounds: image.Rect(0, 0, 300, 1200), max: 256, wantWidth: 64, wantHeight: 256},
+		{name: "square", bounds: image.Rect(0, 0, 1000, 1000), max: 256, wantWidth: 256, wantHeight: 256},
+		{name: "thin strip keeps a pixel", bounds: image.Rect(0, 0, 5000, 1), max: 256, wantWidth: 256, wantHeight: 1},
+		{name: "offset bounds", bounds: image.Rect(-300, 40, 300, 340), max: 256, wantWidth: 256, wantHeight: 128},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scaleDown(uniformImage(tt.bounds, color.Black), tt.max).Bounds()
+			if got != image.Rect(0, 0, tt.wantWidth, tt.wantHeight) {
+				t.Errorf("scaleDown() bounds = %v, want %dx%d at the origin", got, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestScaleDownColors(t *testing.T) {
+	red := color.NRGBA{R: 0xff, A: 0xff}
+	blue := color.NRGBA{B: 0xff, A: 0xff}
+	halves := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	halves.Set(0, 0, red)
+	halves.Set(1, 0, blue)
+
+	tests := []struct {
+		name   string
+		source image.Image
+		max    int
+		want   color.RGBA
+	}{
+		{name: "opaque color is kept", source: uniformImage(image.Rect(0, 0, 8, 8), red), max: 4, want: color.RGBA{R: 0xff, A: 0xff}},
+		{name: "transparent becomes white", source: uniformImage(image.Rect(0, 0, 8, 8), color.Transparent), max: 4, want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{name: "half transparent black becomes grey", source: uniformImage(image.Rect(0, 0, 8, 8), color.NRGBA{A: 0x80}), max: 4, want: color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}},
+		{name: "covered pixels are averaged", source: halves, max: 1, want: color.RGBA{R: 0x7f, B: 0x7f, A: 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scaleDown(tt.source, tt.max)
+			if c := got.RGBAAt(0, 0); c != tt.want {
+				t.Errorf("scaleDown() pixel = %+v, want %+v", c, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderThumbnail(t *testing.T) {
+	encodePNG := func(img image.Image) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("png.Encode() error = %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantWidth  int
+		wantHeight int
+		wantErr    bool
+	}{
+		{name: "large image is scaled down", data: encodePNG(uniformImage(image.Rect(0, 0, 1024, 768), color.White)), wantWidth: thumbnailMaxDimension, wantHeight: 192},
+		{name: "small image keeps its size", data: encodePNG(uniformImage(image.Rect(0, 0, 32, 16), color.White)), wantWidth: 32, wantHeight: 16},
+		{name: "not an image", data: []byte("%PDF-1.7"), wantErr: true},
+		{name: "truncated image", data: pngHeader(64, 64), wantErr: true},
+		{name: "too many pixels", data: pngHeader(10_000, 10_000), wantErr: true},
+		{name: "empty image", data: pngHeader(0, 64), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumbnail, err := renderThumbnail(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderThumbnail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			decoded, err := jpeg.Decode(bytes.NewReader(thumbnail))
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+			if got := decoded.Bounds(); got.Dx() != tt.wantWidth || got.Dy() != tt.wantHeight {
+				t.Errorf("thumbnail size = %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
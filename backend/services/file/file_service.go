@@ -0,0 +1,345 @@
+package file
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	s3service "github.com/AnshJain-Shwalia/DataHub/backend/services/s3"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FileService handles file metadata operations for a single user's files
+type FileService struct{}
+
+// NewFileService creates a new instance of FileService
+func NewFileService() *FileService {
+	return &FileService{}
+}
+
+// FileResponse is the API representation of a file
+type FileResponse struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	FolderID         *string   `json:"folderId"`
+	Size             int64     `json:"size"`
+	Status           string    `json:"status"`
+	ContentType      *string   `json:"contentType"`
+	CurrentVersionID *string   `json:"currentVersionId"`
+	ClientEncrypted  bool      `json:"clientEncrypted"`
+	WrappedKey       *string   `json:"wrappedKey,omitempty"` // the data key of a client-encrypted file, wrapped by the user's key
+	CreatedAt        time.Time `json:"createdAt"`
+	// Tags and Metadata are only included in file details
+	Tags     []string          `json:"tags,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// RenameFileRequest represents the request structure for renaming a file
+type RenameFileRequest struct {
+	Name           string `json:"name" binding:"required"`
+	ConflictPolicy string `json:"conflictPolicy"` // "fail" (default), "rename" or "overwrite"
+}
+
+// MoveFileRequest represents the request structure for moving a file.
+// A nil destination moves the file to the user's root; Name optionally renames it in the same step.
+type MoveFileRequest struct {
+	DestinationFolderID *string `json:"destinationFolderId"`
+	Name                *string `json:"name"`
+	ConflictPolicy      string  `json:"conflictPolicy"` // "fail" (default), "rename" or "overwrite"
+}
+
+// CopyFileRequest represents the request structure for copying a file.
+// A nil destination copies the file into the user's root; Name defaults to the source file's name.
+type CopyFileRequest struct {
+	DestinationFolderID *string `json:"destinationFolderId"`
+	Name                *string `json:"name"`
+	ConflictPolicy      string  `json:"conflictPolicy"` // "fail" (default), "rename" or "overwrite"
+}
+
+// FileError represents a structured error for file operations
+type FileError struct {
+	Message string
+	Code    string
+	Details string
+}
+
+func (e *FileError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// NewFileResponse converts a File model into its API representation
+func NewFileResponse(file *models.File) *FileResponse {
+	return &FileResponse{
+		ID:               file.ID,
+		Name:             file.Name,
+		FolderID:         file.FolderID,
+		Size:             file.Size,
+		Status:           file.Status,
+		ContentType:      file.ContentType,
+		CurrentVersionID: file.CurrentVersionID,
+		ClientEncrypted:  file.ClientEncrypted,
+		WrappedKey:       file.ClientWrappedKey,
+		CreatedAt:        file.CreatedAt,
+	}
+}
+
+// GetFile retrieves a single file owned by the user, including its tags and metadata
+func (s *FileService) GetFile(userID, fileID string) (*FileResponse, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := repositories.GetFileTags(file.ID)
+	if err != nil {
+		return nil, &FileError{
+			Message: "Failed to retrieve file tags",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	metadata, err := repositories.GetFileMetadata(file.ID)
+	if err != nil {
+		return nil, &FileError{
+			Message: "Failed to retrieve file metadata",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	response := NewFileResponse(file)
+	response.Tags = tags
+	response.Metadata = metadata
+	return response, nil
+}
+
+// RenameFile changes the name of a file owned by the user without moving it
+func (s *FileService) RenameFile(userID, fileID string, request *RenameFileRequest) (*FileResponse, error) {
+	if _, err := s.getOwnedFile(userID, fileID); err != nil {
+		return nil, err
+	}
+	if err := util.ValidateItemName(request.Name); err != nil {
+		return nil, &FileError{
+			Message: "Invalid file name",
+			Code:    "INVALID_NAME",
+			Details: err.Error(),
+		}
+	}
+
+	// The folder is read inside the rename's transaction, so a concurrent move is kept
+	file, err := repositories.RenameFile(fileID, userID, request.Name, request.ConflictPolicy)
+	if err != nil {
+		return nil, moveFileError(err)
+	}
+	return NewFileResponse(file), nil
+}
+
+// MoveFile moves a file owned by the user into another of the user's folders,
+// optionally renaming it as part of the same transaction
+func (s *FileService) MoveFile(userID, fileID string, request *MoveFileRequest) (*FileResponse, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := file.Name
+	if request.Name != nil {
+		name = *request.Name
+	}
+	return s.moveFile(userID, fileID, request.DestinationFolderID, name, request.ConflictPolicy)
+}
+
+// moveFile validates the target name and location and applies the move
+func (s *FileService) moveFile(userID, fileID string, destinationFolderID *string, name, conflictPolicy string) (*FileResponse, error) {
+	if err := util.ValidateItemName(name); err != nil {
+		return nil, &FileError{
+			Message: "Invalid file name",
+			Code:    "INVALID_NAME",
+			Details: err.Error(),
+		}
+	}
+	if destinationFolderID != nil && uuid.Validate(*destinationFolderID) != nil {
+		return nil, &FileError{
+			Message: "Destination folder not found",
+			Code:    "DESTINATION_NOT_FOUND",
+		}
+	}
+
+	file, err := repositories.MoveFile(fileID, userID, destinationFolderID, name, conflictPolicy)
+	if err != nil {
+		return nil, moveFileError(err)
+	}
+	return NewFileResponse(file), nil
+}
+
+// moveFileError converts an error of a file move or rename into a FileError
+func moveFileError(err error) error {
+	if conflictErr := nameConflictError(err); conflictErr != nil {
+		return conflictErr
+	}
+	switch {
+	case errors.Is(err, repositories.ErrDestinationNotFound):
+		return &FileError{
+			Message: "Destination folder not found",
+			Code:    "DESTINATION_NOT_FOUND",
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &FileError{
+			Message: "File not found",
+			Code:    "FILE_NOT_FOUND",
+		}
+	}
+	return &FileError{
+		Message: "Failed to move file",
+		Code:    "FILE_UPDATE_FAILED",
+		Details: err.Error(),
+	}
+}
+
+// CopyFile copies the current version of a file owned by the user into one of the user's folders.
+// The copy shares its stored content with the source, so no data is uploaded again.
+func (s *FileService) CopyFile(userID, fileID string, request *CopyFileRequest) (*FileResponse, error) {
+	source, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := source.Name
+	if request.Name != nil {
+		name = *request.Name
+	}
+	if err := util.ValidateItemName(name); err != nil {
+		return nil, &FileError{
+			Message: "Invalid file name",
+			Code:    "INVALID_NAME",
+			Details: err.Error(),
+		}
+	}
+	if request.DestinationFolderID != nil && uuid.Validate(*request.DestinationFolderID) != nil {
+		return nil, &FileError{
+			Message: "Destination folder not found",
+			Code:    "DESTINATION_NOT_FOUND",
+		}
+	}
+
+	file, version, err := repositories.CopyFile(fileID, userID, request.DestinationFolderID, name, request.ConflictPolicy)
+	if err != nil {
+		if conflictErr := nameConflictError(err); conflictErr != nil {
+			return nil, conflictErr
+		}
+		switch {
+		case errors.Is(err, repositories.ErrVersionIncomplete):
+			return nil, &FileError{
+				Message: "File upload has not completed",
+				Code:    "UPLOAD_INCOMPLETE",
+			}
+		case errors.Is(err, repositories.ErrEncryptionMismatch):
+			return nil, encryptionMismatchError()
+		case errors.Is(err, repositories.ErrDestinationNotFound):
+			return nil, &FileError{
+				Message: "Destination folder not found",
+				Code:    "DESTINATION_NOT_FOUND",
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, &FileError{
+				Message: "File not found",
+				Code:    "FILE_NOT_FOUND",
+			}
+		}
+		return nil, &FileError{
+			Message: "Failed to copy file",
+			Code:    "FILE_CREATION_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	// Thumbnails belong to a single version, so the copy renders its own from the shared buffer
+	if canThumbnail(version) {
+		if s3Service, err := s3service.NewS3Service(); err == nil {
+			queueThumbnail(s3Service, userID, version, version.Chunks)
+		}
+	}
+	return NewFileResponse(file), nil
+}
+
+// DeleteFile moves a file owned by the user to the trash
+func (s *FileService) DeleteFile(userID, fileID string) error {
+	if _, err := s.getOwnedFile(userID, fileID); err != nil {
+		return err
+	}
+
+	if err := repositories.TrashFile(fileID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &FileError{
+				Message: "File not found",
+				Code:    "FILE_NOT_FOUND",
+			}
+		}
+		return &FileError{
+			Message: "Failed to delete file",
+			Code:    "FILE_DELETE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
+
+// nameConflictError converts the repository's naming errors into FileErrors; nil is returned for other errors
+func nameConflictError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrNameConflict):
+		return &FileError{
+			Message: "An item with the same name already exists",
+			Code:    "NAME_CONFLICT",
+		}
+	case errors.Is(err, repositories.ErrInvalidConflictPolicy):
+		return &FileError{
+			Message: "Invalid conflict policy",
+			Code:    "INVALID_CONFLICT_POLICY",
+			Details: "conflictPolicy must be fail, rename or overwrite",
+		}
+	}
+	return nil
+}
+
+// encryptionMismatchError reports a version whose end-to-end encryption doesn't match its file
+func encryptionMismatchError() error {
+	return &FileError{
+		Message: "Content encryption does not match the existing file",
+		Code:    "ENCRYPTION_MISMATCH",
+		Details: "versions of a file must all be plaintext or all be encrypted with the file's wrapped key",
+	}
+}
+
+// getOwnedFile loads a file that must belong to the user
+func (s *FileService) getOwnedFile(userID, fileID string) (*models.File, error) {
+	// Malformed IDs can never match a file, so report them as missing instead of a database error
+	if uuid.Validate(fileID) != nil {
+		return nil, &FileError{
+			Message: "File not found",
+			Code:    "FILE_NOT_FOUND",
+		}
+	}
+
+	file, err := repositories.FindFileByIDForUser(fileID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &FileError{
+				Message: "File not found",
+				Code:    "FILE_NOT_FOUND",
+			}
+		}
+		return nil, &FileError{
+			Message: "Failed to retrieve file",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return file, nil
+}
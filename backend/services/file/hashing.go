@@ -0,0 +1,117 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+)
+
+// hashBatchSize is the number of versions the hash worker loads at a time
+const hashBatchSize = 20
+
+// hashRequests wakes the hash worker when an upload has completed, so new content is usually
+// hashed right away instead of at the next interval
+var hashRequests = make(chan struct{}, 1)
+
+// requestContentHashing asks the hash worker to run without waiting for it
+func requestContentHashing() {
+	select {
+	case hashRequests <- struct{}{}:
+	default:
+	}
+}
+
+// StartHashWorker hashes the content of completed versions in the background, at the configured
+// interval and whenever an upload completes. Uploads are deduplicated against these hashes only,
+// so hashing stays out of the upload requests and never trusts a hash a client claimed.
+func (s *FileService) StartHashWorker() {
+	interval := time.Duration(config.LoadConfig().ContentHashIntervalMinutes) * time.Minute
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		// Versions whose content could not be read are retried after a restart, not on every run
+		failed := make(map[string]bool)
+		for {
+			hashed, err := hashPendingVersions(failed)
+			if err != nil {
+				log.Printf("Content hashing failed: %v", err)
+			} else if hashed > 0 {
+				log.Printf("Hashed the content of %d file versions", hashed)
+			}
+			select {
+			case <-ticker.C:
+			case <-hashRequests:
+			}
+		}
+	}()
+}
+
+// hashPendingVersions hashes every completed version that has not been hashed yet and returns how
+// many were hashed. Versions that fail are logged and added to failed.
+func hashPendingVersions(failed map[string]bool) (int, error) {
+	hashed := 0
+	for {
+		skipIDs := make([]string, 0, len(failed))
+		for id := range failed {
+			skipIDs = append(skipIDs, id)
+		}
+		versions, err := repositories.FindVersionsToHash(skipIDs, hashBatchSize)
+		if err != nil {
+			return hashed, err
+		}
+		if len(versions) == 0 {
+			return hashed, nil
+		}
+
+		for _, version := range versions {
+			versionHash, chunkHashes, err := hashVersionContent(version.ID)
+			if err == nil {
+				err = repositories.SaveContentHashes(version.ID, versionHash, chunkHashes)
+			}
+			if err != nil {
+				log.Printf("Failed to hash the content of version %s: %v", version.ID, err)
+				failed[version.ID] = true
+				continue
+			}
+			hashed++
+		}
+	}
+}
+
+// hashVersionContent reads the stored content of a version in rank order and returns the hex SHA-256
+// of the whole content and of every chunk, keyed by chunk ID
+func hashVersionContent(versionID string) (string, map[string]string, error) {
+	chunks, err := repositories.GetChunksForVersion(versionID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	stored := &Download{}
+	fileHasher := sha256.New()
+	chunkHashes := make(map[string]string, len(chunks))
+	for i := range chunks {
+		chunk := &chunks[i]
+		content, err := stored.openChunk(chunk)
+		if err != nil {
+			return "", nil, err
+		}
+		chunkHasher := sha256.New()
+		// Never read past the chunk, in case the buffered object was replaced after finalize
+		read, err := io.Copy(io.MultiWriter(chunkHasher, fileHasher), io.LimitReader(content, chunk.Size+1))
+		content.Close()
+		if err != nil {
+			return "", nil, err
+		}
+		if read != chunk.Size {
+			return "", nil, fmt.Errorf("chunk %s holds %d bytes, expected %d", chunk.ID, read, chunk.Size)
+		}
+		chunkHashes[chunk.ID] = hex.EncodeToString(chunkHasher.Sum(nil))
+	}
+	return hex.EncodeToString(fileHasher.Sum(nil)), chunkHashes, nil
+}
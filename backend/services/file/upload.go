@@ -0,0 +1,370 @@
+package file
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	s3service "github.com/AnshJain-Shwalia/DataHub/backend/services/s3"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InitiateUploadRequest represents the request structure for starting a file upload
+type InitiateUploadRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	FolderID       *string `json:"folderId"`
+	Size           int64   `json:"size" binding:"min=0"`
+	ConflictPolicy string  `json:"conflictPolicy"` // "fail" (default), "rename" or "overwrite" (adds a new version to the existing file)
+	// Hash optionally gives the hex SHA-256 of the whole file. When the user already stores a file
+	// with identical content, the upload completes immediately without sending any data.
+	Hash *string `json:"hash"`
+	// ChunkHashes optionally lists the hex SHA-256 of every chunk in rank order. Chunks whose content
+	// is already stored for the user are not uploaded again.
+	ChunkHashes []string `json:"chunkHashes"`
+	// ClientEncrypted marks content that the client encrypts end-to-end. WrappedKey is then the file's
+	// data key wrapped by the user's key; when the upload adds a version to an existing encrypted file,
+	// it must be that file's wrapped key. Hashes are not accepted for encrypted content.
+	ClientEncrypted bool    `json:"clientEncrypted"`
+	WrappedKey      *string `json:"wrappedKey"`
+}
+
+// ChunkUploadResponse tells the client where to upload a single chunk
+type ChunkUploadResponse struct {
+	ID        string    `json:"id"`
+	Rank      int       `json:"rank"`
+	Size      int64     `json:"size"`
+	UploadURL string    `json:"uploadUrl"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// InitiateUploadResponse represents the response structure after starting a file upload
+type InitiateUploadResponse struct {
+	Success bool                  `json:"success"`
+	File    *FileResponse         `json:"file"`
+	Version *FileVersionResponse  `json:"version"`
+	Chunks  []ChunkUploadResponse `json:"chunks"` // the chunks the client must upload
+	// Complete is true when the content was already stored and the upload needs neither chunks nor finalizing
+	Complete bool `json:"complete"`
+	// Deduplicated lists the ranks of the chunks whose content is already stored and must not be uploaded
+	Deduplicated []int `json:"deduplicated"`
+}
+
+// chunkSize returns the maximum size of a single chunk in bytes
+func chunkSize() int64 {
+	return int64(config.LoadConfig().S3MaxUploadSizeMB) * 1024 * 1024
+}
+
+// splitIntoChunks returns the sizes of the chunks a file of the given size is split into
+func splitIntoChunks(size int64) []int64 {
+	maxChunk := chunkSize()
+	var sizes []int64
+	for remaining := size; remaining > 0; remaining -= maxChunk {
+		sizes = append(sizes, min(remaining, maxChunk))
+	}
+	return sizes
+}
+
+// InitiateUpload creates the metadata of a new file version and its chunks, and returns a
+// pre-signed S3 URL for every chunk that has to be uploaded. The client uploads each of those
+// chunks to its URL and then calls FinalizeUpload. When chunk hashes are given, chunks with
+// content the user already stores are reused instead of being uploaded, and when the hash of
+// the whole file matches content the user already stores the upload is complete at once.
+// Uploading with the overwrite policy onto an existing file adds a version to that file; the
+// previous version stays current until the upload is finalized, and an older upload of the file
+// that was never finalized is aborted.
+func (s *FileService) InitiateUpload(userID string, request *InitiateUploadRequest) (*InitiateUploadResponse, error) {
+	if err := util.ValidateItemName(request.Name); err != nil {
+		return nil, &FileError{
+			Message: "Invalid file name",
+			Code:    "INVALID_NAME",
+			Details: err.Error(),
+		}
+	}
+
+	if err := s.validateClientEncryption(userID, request); err != nil {
+		return nil, err
+	}
+	if request.Hash != nil && !util.IsSHA256Hex(*request.Hash) {
+		return nil, &FileError{
+			Message: "Invalid file hash",
+			Code:    "INVALID_HASH",
+			Details: "hash must be a lowercase hex SHA-256 digest",
+		}
+	}
+	sizes := splitIntoChunks(request.Size)
+	if err := util.ValidateChunkHashes(request.ChunkHashes, len(sizes)); err != nil {
+		return nil, &FileError{
+			Message: "Invalid chunk hashes",
+			Code:    "INVALID_CHUNK_HASHES",
+			Details: err.Error(),
+		}
+	}
+
+	if request.FolderID != nil {
+		if err := s.ensureFolderOwned(userID, *request.FolderID); err != nil {
+			return nil, err
+		}
+	}
+
+	s3Service, err := s3service.NewS3Service()
+	if err != nil {
+		return nil, &FileError{
+			Message: "Storage buffer is unavailable",
+			Code:    "STORAGE_UNAVAILABLE",
+			Details: err.Error(),
+		}
+	}
+
+	var clientWrappedKey *string
+	if request.ClientEncrypted {
+		clientWrappedKey = request.WrappedKey
+	}
+	file, version, aborted, err := repositories.CreateFileWithChunks(request.Name, request.Size, request.Hash, userID, request.FolderID, sizes, request.ChunkHashes,
+		func(fileID, chunkID string) string {
+			return s3service.ChunkKey(userID, fileID, chunkID)
+		}, request.ConflictPolicy, clientWrappedKey)
+	if err != nil {
+		if conflictErr := nameConflictError(err); conflictErr != nil {
+			return nil, conflictErr
+		}
+		if errors.Is(err, repositories.ErrEncryptionMismatch) {
+			return nil, encryptionMismatchError()
+		}
+		return nil, &FileError{
+			Message: "Failed to create file",
+			Code:    "FILE_CREATION_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	// Older pending uploads of the file can never be finalized, so their buffered chunks are removed
+	if err := DeleteReleasedBlobs(aborted); err != nil {
+		log.Printf("Failed to delete content of aborted uploads of file %s: %v", file.ID, err)
+	}
+
+	response := &InitiateUploadResponse{
+		Success:      true,
+		File:         NewFileResponse(file),
+		Version:      NewFileVersionResponse(version, file),
+		Chunks:       make([]ChunkUploadResponse, 0, len(version.Chunks)),
+		Deduplicated: []int{},
+	}
+	if version.Status == models.FileStatusComplete {
+		response.Complete = true
+		if canThumbnail(version) {
+			queueThumbnail(s3Service, userID, version, version.Chunks)
+		}
+		return response, nil
+	}
+	for _, chunk := range version.Chunks {
+		if chunk.Status != models.ChunkStatusPending {
+			response.Deduplicated = append(response.Deduplicated, chunk.Rank)
+			continue
+		}
+		upload, err := s3Service.GenerateUploadURL(map[string]string{
+			"fileId":  file.ID,
+			"userId":  userID,
+			"chunkId": chunk.ID,
+		})
+		if err != nil {
+			return nil, &FileError{
+				Message: "Failed to generate chunk upload URL",
+				Code:    "UPLOAD_URL_FAILED",
+				Details: err.Error(),
+			}
+		}
+		response.Chunks = append(response.Chunks, ChunkUploadResponse{
+			ID:        chunk.ID,
+			Rank:      chunk.Rank,
+			Size:      chunk.Size,
+			UploadURL: upload.UploadURL,
+			ExpiresAt: upload.ExpiresAt,
+		})
+	}
+	return response, nil
+}
+
+// FinalizeUpload verifies that every chunk of the newest pending version of a file is present
+// in the S3 buffer with the expected size, marks the chunks as buffered and makes the version
+// the file's current version. The content type is sniffed from the first chunk, images get a
+// thumbnail generated in the background, and the content is hashed in the background so later
+// uploads can be deduplicated against it.
+func (s *FileService) FinalizeUpload(userID, fileID string) (*FileResponse, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := repositories.FindLatestUploadingVersion(file.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Nothing is pending, so finalizing again is a no-op
+			return NewFileResponse(file), nil
+		}
+		return nil, &FileError{
+			Message: "Failed to retrieve file version",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	chunks, err := repositories.GetChunksForVersion(version.ID)
+	if err != nil {
+		return nil, &FileError{
+			Message: "Failed to retrieve file chunks",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	s3Service, err := s3service.NewS3Service()
+	if err != nil {
+		return nil, &FileError{
+			Message: "Storage buffer is unavailable",
+			Code:    "STORAGE_UNAVAILABLE",
+			Details: err.Error(),
+		}
+	}
+
+	var uploaded []string
+	for _, chunk := range chunks {
+		if chunk.Status != models.ChunkStatusPending || chunk.S3Path == nil {
+			continue
+		}
+		size, err := s3Service.GetObjectSize(*chunk.S3Path)
+		if err != nil {
+			if errors.Is(err, s3service.ErrObjectNotFound) {
+				return nil, &FileError{
+					Message: "Not all chunks have been uploaded",
+					Code:    "UPLOAD_INCOMPLETE",
+					Details: "missing chunk " + chunk.ID,
+				}
+			}
+			return nil, &FileError{
+				Message: "Failed to verify uploaded chunks",
+				Code:    "STORAGE_UNAVAILABLE",
+				Details: err.Error(),
+			}
+		}
+		if size != chunk.Size {
+			return nil, &FileError{
+				Message: "Uploaded chunk has an unexpected size",
+				Code:    "UPLOAD_INCOMPLETE",
+				Details: "chunk " + chunk.ID,
+			}
+		}
+		uploaded = append(uploaded, chunk.ID)
+	}
+
+	if err := repositories.MarkChunksBuffered(uploaded); err != nil {
+		return nil, &FileError{
+			Message: "Failed to update chunk status",
+			Code:    "FILE_UPDATE_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	// The content of end-to-end encrypted files is never inspected
+	var contentType *string
+	if !file.ClientEncrypted {
+		contentType, err = sniffContentType(s3Service, file.Name, chunks)
+		if err != nil {
+			return nil, &FileError{
+				Message: "Failed to read uploaded content",
+				Code:    "STORAGE_UNAVAILABLE",
+				Details: err.Error(),
+			}
+		}
+	}
+	if err := repositories.CompleteFileVersion(file, version, contentType); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &FileError{
+				Message: "Upload was aborted by a newer upload or has already been finalized",
+				Code:    "UPLOAD_INCOMPLETE",
+			}
+		}
+		return nil, &FileError{
+			Message: "Failed to update file status",
+			Code:    "FILE_UPDATE_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	if !file.ClientEncrypted {
+		requestContentHashing()
+		if canThumbnail(version) {
+			queueThumbnail(s3Service, userID, version, chunks)
+		}
+	}
+	return NewFileResponse(file), nil
+}
+
+// validateClientEncryption checks that the encryption fields of an upload are consistent and that
+// the user has set up end-to-end encryption before uploading encrypted content
+func (s *FileService) validateClientEncryption(userID string, request *InitiateUploadRequest) error {
+	invalid := func(details string) error {
+		return &FileError{
+			Message: "Invalid encryption settings",
+			Code:    "INVALID_ENCRYPTION",
+			Details: details,
+		}
+	}
+	if !request.ClientEncrypted {
+		if request.WrappedKey != nil {
+			return invalid("wrappedKey is only accepted for client-encrypted uploads")
+		}
+		return nil
+	}
+
+	if request.WrappedKey == nil || *request.WrappedKey == "" {
+		return invalid("wrappedKey is required for client-encrypted uploads")
+	}
+	if len(*request.WrappedKey) > 4096 {
+		return invalid("wrappedKey must be at most 4096 bytes")
+	}
+	// Hashes of the plaintext would let the server confirm guesses about the content
+	if request.Hash != nil || request.ChunkHashes != nil {
+		return invalid("hashes are not accepted for client-encrypted uploads")
+	}
+
+	if _, err := repositories.FindUserKey(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &FileError{
+				Message: "End-to-end encryption has not been set up",
+				Code:    "ENCRYPTION_NOT_CONFIGURED",
+			}
+		}
+		return &FileError{
+			Message: "Failed to retrieve key",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
+
+// ensureFolderOwned checks that a destination folder exists and belongs to the user
+func (s *FileService) ensureFolderOwned(userID, folderID string) error {
+	if uuid.Validate(folderID) == nil {
+		_, err := repositories.FindFolderByIDForUser(folderID, userID)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return &FileError{
+				Message: "Failed to retrieve folder",
+				Code:    "FILE_RETRIEVAL_FAILED",
+				Details: err.Error(),
+			}
+		}
+	}
+	return &FileError{
+		Message: "Destination folder not found",
+		Code:    "DESTINATION_NOT_FOUND",
+	}
+}
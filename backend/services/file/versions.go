@@ -0,0 +1,253 @@
+package file
+
+import (
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	s3service "github.com/AnshJain-Shwalia/DataHub/backend/services/s3"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FileVersionResponse is the API representation of a file version
+type FileVersionResponse struct {
+	ID           string    `json:"id"`
+	Number       int       `json:"number"`
+	Size         int64     `json:"size"`
+	Status       string    `json:"status"`
+	ContentType  *string   `json:"contentType"`
+	HasThumbnail bool      `json:"hasThumbnail"`
+	IsCurrent    bool      `json:"isCurrent"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ListVersionsResponse represents the response structure for listing a file's versions
+type ListVersionsResponse struct {
+	Success  bool                  `json:"success"`
+	Versions []FileVersionResponse `json:"versions"`
+}
+
+// PruneVersionsRequest represents the request structure for pruning old versions of a file.
+// At least one rule must be given; a version is pruned when it matches any of them.
+// The current version is always kept.
+type PruneVersionsRequest struct {
+	KeepLatest    *int `json:"keepLatest" binding:"omitempty,min=1"`    // keep this many newest versions
+	OlderThanDays *int `json:"olderThanDays" binding:"omitempty,min=0"` // prune versions created more than this many days ago
+}
+
+// PruneVersionsResponse represents the response structure after pruning versions
+type PruneVersionsResponse struct {
+	Success  bool     `json:"success"`
+	Pruned   int      `json:"pruned"`
+	Versions []string `json:"versions"`
+}
+
+// NewFileVersionResponse converts a FileVersion model into its API representation
+func NewFileVersionResponse(version *models.FileVersion, file *models.File) *FileVersionResponse {
+	return &FileVersionResponse{
+		ID:           version.ID,
+		Number:       version.Number,
+		Size:         version.Size,
+		Status:       version.Status,
+		ContentType:  version.ContentType,
+		HasThumbnail: version.ThumbnailKey != nil,
+		IsCurrent:    file.CurrentVersionID != nil && *file.CurrentVersionID == version.ID,
+		CreatedAt:    version.CreatedAt,
+	}
+}
+
+// ListVersions returns every version of a file owned by the user, newest first
+func (s *FileService) ListVersions(userID, fileID string) (*ListVersionsResponse, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := repositories.ListFileVersions(file.ID)
+	if err != nil {
+		return nil, &FileError{
+			Message: "Failed to retrieve file versions",
+			Code:    "FILE_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	response := &ListVersionsResponse{
+		Success:  true,
+		Versions: make([]FileVersionResponse, 0, len(versions)),
+	}
+	for i := range versions {
+		response.Versions = append(response.Versions, *NewFileVersionResponse(&versions[i], file))
+	}
+	return response, nil
+}
+
+// PrepareVersionDownload prepares the download of a specific completed version of a file
+func (s *FileService) PrepareVersionDownload(userID, fileID, versionID string) (*Download, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.getFileVersion(file, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return prepareDownload(file, version)
+}
+
+// PromoteVersion makes an older completed version the current version of a file.
+// The versions in between are kept, so the promotion can itself be undone.
+func (s *FileService) PromoteVersion(userID, fileID, versionID string) (*FileResponse, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.getFileVersion(file, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repositories.SetCurrentVersion(file, version); err != nil {
+		if errors.Is(err, repositories.ErrVersionIncomplete) {
+			return nil, &FileError{
+				Message: "File version upload has not completed",
+				Code:    "UPLOAD_INCOMPLETE",
+			}
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The version was pruned after it was looked up
+			return nil, &FileError{
+				Message: "File version not found",
+				Code:    "VERSION_NOT_FOUND",
+			}
+		}
+		return nil, &FileError{
+			Message: "Failed to update file version",
+			Code:    "FILE_UPDATE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return NewFileResponse(file), nil
+}
+
+// PruneVersions permanently deletes the completed versions of a file that fall outside the
+// requested retention rules. Once the versions are deleted, the chunk blobs and thumbnails no
+// other version references are removed from GitHub and the S3 buffer.
+func (s *FileService) PruneVersions(userID, fileID string, request *PruneVersionsRequest) (*PruneVersionsResponse, error) {
+	if request.KeepLatest == nil && request.OlderThanDays == nil {
+		return nil, &FileError{
+			Message: "Invalid prune rules",
+			Code:    "INVALID_PRUNE_RULES",
+			Details: "keepLatest or olderThanDays is required",
+		}
+	}
+
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cutoff *time.Time
+	if request.OlderThanDays != nil {
+		before := time.Now().AddDate(0, 0, -*request.OlderThanDays)
+		cutoff = &before
+	}
+	versionIDs, released, err := repositories.PruneFileVersions(file.ID, userID, request.KeepLatest, cutoff)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &FileError{
+				Message: "File not found",
+				Code:    "FILE_NOT_FOUND",
+			}
+		}
+		return nil, &FileError{
+			Message: "Failed to delete file versions",
+			Code:    "FILE_UPDATE_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	// The versions are gone, so a blob that can't be removed now is only wasted space
+	if err := DeleteReleasedBlobs(released); err != nil {
+		log.Printf("Failed to delete content of pruned versions of file %s: %v", file.ID, err)
+	}
+
+	if versionIDs == nil {
+		versionIDs = []string{}
+	}
+	return &PruneVersionsResponse{
+		Success:  true,
+		Pruned:   len(versionIDs),
+		Versions: versionIDs,
+	}, nil
+}
+
+// OpenThumbnail opens the thumbnail of the current version of a file owned by the user.
+// The caller must close the returned reader.
+func (s *FileService) OpenThumbnail(userID, fileID string) (io.ReadCloser, error) {
+	file, err := s.getOwnedFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	notFound := &FileError{
+		Message: "File has no thumbnail",
+		Code:    "THUMBNAIL_NOT_FOUND",
+	}
+	if file.CurrentVersionID == nil {
+		return nil, notFound
+	}
+	version, err := s.getFileVersion(file, *file.CurrentVersionID)
+	if err != nil {
+		return nil, err
+	}
+	if version.ThumbnailKey == nil {
+		return nil, notFound
+	}
+
+	s3Service, err := s3service.NewS3Service()
+	if err != nil {
+		return nil, &FileError{
+			Message: "Storage buffer is unavailable",
+			Code:    "STORAGE_UNAVAILABLE",
+			Details: err.Error(),
+		}
+	}
+	thumbnail, err := s3Service.GetObject(*version.ThumbnailKey)
+	if err != nil {
+		if errors.Is(err, s3service.ErrObjectNotFound) {
+			return nil, notFound
+		}
+		return nil, &FileError{
+			Message: "Failed to read thumbnail",
+			Code:    "STORAGE_UNAVAILABLE",
+			Details: err.Error(),
+		}
+	}
+	return thumbnail, nil
+}
+
+// getFileVersion loads a version that must belong to the file
+func (s *FileService) getFileVersion(file *models.File, versionID string) (*models.FileVersion, error) {
+	// Malformed IDs can never match a version, so report them as missing instead of a database error
+	if uuid.Validate(versionID) == nil {
+		version, err := repositories.FindFileVersion(file.ID, versionID)
+		if err == nil {
+			return version, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &FileError{
+				Message: "Failed to retrieve file version",
+				Code:    "FILE_RETRIEVAL_FAILED",
+				Details: err.Error(),
+			}
+		}
+	}
+	return nil, &FileError{
+		Message: "File version not found",
+		Code:    "VERSION_NOT_FOUND",
+	}
+}
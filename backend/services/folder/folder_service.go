@@ -0,0 +1,313 @@
+package folder
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FolderService handles folder management operations for a single user's folder tree
+type FolderService struct{}
+
+// NewFolderService creates a new instance of FolderService
+func NewFolderService() *FolderService {
+	return &FolderService{}
+}
+
+// CreateFolderRequest represents the request structure for creating a folder
+type CreateFolderRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	ParentFolderID *string `json:"parentFolderId"`
+}
+
+// RenameFolderRequest represents the request structure for renaming a folder
+type RenameFolderRequest struct {
+	Name           string `json:"name" binding:"required"`
+	ConflictPolicy string `json:"conflictPolicy"` // "fail" (default) or "rename"
+}
+
+// MoveFolderRequest represents the request structure for moving a folder.
+// A nil destination moves the folder to the user's root; Name optionally renames it in the same step.
+type MoveFolderRequest struct {
+	DestinationParentID *string `json:"destinationParentId"`
+	Name                *string `json:"name"`
+	ConflictPolicy      string  `json:"conflictPolicy"` // "fail" (default) or "rename"; folders cannot be overwritten
+}
+
+// FolderResponse is the API representation of a folder
+type FolderResponse struct {
+	ID             string               `json:"id"`
+	Name           string               `json:"name"`
+	ParentFolderID *string              `json:"parentFolderId"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	Stats          *FolderStatsResponse `json:"stats,omitempty"` // only included in folder details
+}
+
+// FolderStatsResponse is the API representation of the recursive size and item counts of a folder
+type FolderStatsResponse struct {
+	TotalBytes  int64 `json:"totalBytes"`
+	FileCount   int64 `json:"fileCount"`
+	FolderCount int64 `json:"folderCount"`
+}
+
+// FolderError represents a structured error for folder operations
+type FolderError struct {
+	Message string
+	Code    string
+	Details string
+}
+
+func (e *FolderError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// NewFolderResponse converts a Folder model into its API representation
+func NewFolderResponse(folder *models.Folder) *FolderResponse {
+	return &FolderResponse{
+		ID:             folder.ID,
+		Name:           folder.Name,
+		ParentFolderID: folder.ParentFolderID,
+		CreatedAt:      folder.CreatedAt,
+	}
+}
+
+// CreateFolder creates a folder for the user, optionally inside a parent folder.
+// The parent folder, when given, must belong to the same user.
+func (s *FolderService) CreateFolder(userID string, request *CreateFolderRequest) (*FolderResponse, error) {
+	if err := util.ValidateItemName(request.Name); err != nil {
+		return nil, &FolderError{
+			Message: "Invalid folder name",
+			Code:    "INVALID_NAME",
+			Details: err.Error(),
+		}
+	}
+
+	if request.ParentFolderID != nil {
+		if _, err := s.getOwnedFolder(userID, *request.ParentFolderID, "PARENT_NOT_FOUND"); err != nil {
+			return nil, err
+		}
+	}
+
+	folder, err := repositories.CreateFolder(request.Name, userID, request.ParentFolderID)
+	if err != nil {
+		if conflictErr := nameConflictError(err); conflictErr != nil {
+			return nil, conflictErr
+		}
+		return nil, &FolderError{
+			Message: "Failed to create folder",
+			Code:    "FOLDER_CREATION_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	return NewFolderResponse(folder), nil
+}
+
+// NewFolderStatsResponse converts aggregate folder figures into their API representation
+func NewFolderStatsResponse(stats repositories.FolderStats) *FolderStatsResponse {
+	return &FolderStatsResponse{
+		TotalBytes:  stats.TotalBytes,
+		FileCount:   stats.FileCount,
+		FolderCount: stats.FolderCount,
+	}
+}
+
+// GetFolder retrieves a single folder owned by the user, including the total size
+// and number of items below it
+func (s *FolderService) GetFolder(userID, folderID string) (*FolderResponse, error) {
+	folder, err := s.getOwnedFolder(userID, folderID, "FOLDER_NOT_FOUND")
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := repositories.GetFolderStats(userID, []string{folder.ID})
+	if err != nil {
+		return nil, &FolderError{
+			Message: "Failed to compute folder size",
+			Code:    "FOLDER_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	response := NewFolderResponse(folder)
+	response.Stats = NewFolderStatsResponse(stats[folder.ID])
+	return response, nil
+}
+
+// GetRootStats returns the total size and number of items in the user's whole folder tree
+func (s *FolderService) GetRootStats(userID string) (*FolderStatsResponse, error) {
+	stats, err := repositories.GetRootStats(userID)
+	if err != nil {
+		return nil, &FolderError{
+			Message: "Failed to compute folder size",
+			Code:    "FOLDER_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return NewFolderStatsResponse(*stats), nil
+}
+
+// RenameFolder changes the name of a folder owned by the user without moving it
+func (s *FolderService) RenameFolder(userID, folderID string, request *RenameFolderRequest) (*FolderResponse, error) {
+	if _, err := s.getOwnedFolder(userID, folderID, "FOLDER_NOT_FOUND"); err != nil {
+		return nil, err
+	}
+	if err := util.ValidateItemName(request.Name); err != nil {
+		return nil, &FolderError{
+			Message: "Invalid folder name",
+			Code:    "INVALID_NAME",
+			Details: err.Error(),
+		}
+	}
+
+	// The parent is read inside the rename's transaction, so a concurrent move is kept
+	folder, err := repositories.RenameFolder(folderID, userID, request.Name, request.ConflictPolicy)
+	if err != nil {
+		return nil, moveFolderError(err)
+	}
+	return NewFolderResponse(folder), nil
+}
+
+// MoveFolder moves a folder owned by the user under another of the user's folders,
+// optionally renaming it as part of the same transaction.
+// Moving a folder into itself or one of its descendants is rejected.
+func (s *FolderService) MoveFolder(userID, folderID string, request *MoveFolderRequest) (*FolderResponse, error) {
+	folder, err := s.getOwnedFolder(userID, folderID, "FOLDER_NOT_FOUND")
+	if err != nil {
+		return nil, err
+	}
+
+	name := folder.Name
+	if request.Name != nil {
+		name = *request.Name
+	}
+	return s.moveFolder(userID, folderID, request.DestinationParentID, name, request.ConflictPolicy)
+}
+
+// moveFolder validates the target name and location and applies the move
+func (s *FolderService) moveFolder(userID, folderID string, destinationParentID *string, name, conflictPolicy string) (*FolderResponse, error) {
+	if err := util.ValidateItemName(name); err != nil {
+		return nil, &FolderError{
+			Message: "Invalid folder name",
+			Code:    "INVALID_NAME",
+			Details: err.Error(),
+		}
+	}
+	if destinationParentID != nil && uuid.Validate(*destinationParentID) != nil {
+		return nil, &FolderError{
+			Message: "Destination folder not found",
+			Code:    "DESTINATION_NOT_FOUND",
+		}
+	}
+
+	folder, err := repositories.MoveFolder(folderID, userID, destinationParentID, name, conflictPolicy)
+	if err != nil {
+		return nil, moveFolderError(err)
+	}
+	return NewFolderResponse(folder), nil
+}
+
+// moveFolderError converts an error of a folder move or rename into a FolderError
+func moveFolderError(err error) error {
+	if conflictErr := nameConflictError(err); conflictErr != nil {
+		return conflictErr
+	}
+	switch {
+	case errors.Is(err, repositories.ErrDestinationNotFound):
+		return &FolderError{
+			Message: "Destination folder not found",
+			Code:    "DESTINATION_NOT_FOUND",
+		}
+	case errors.Is(err, repositories.ErrMoveIntoDescendant):
+		return &FolderError{
+			Message: "Cannot move a folder into itself or one of its subfolders",
+			Code:    "INVALID_MOVE",
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &FolderError{
+			Message: "Folder not found",
+			Code:    "FOLDER_NOT_FOUND",
+		}
+	}
+	return &FolderError{
+		Message: "Failed to move folder",
+		Code:    "FOLDER_UPDATE_FAILED",
+		Details: err.Error(),
+	}
+}
+
+// DeleteFolder moves a folder owned by the user, together with all of its contents, to the trash
+func (s *FolderService) DeleteFolder(userID, folderID string) error {
+	if _, err := s.getOwnedFolder(userID, folderID, "FOLDER_NOT_FOUND"); err != nil {
+		return err
+	}
+
+	if err := repositories.TrashFolder(folderID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &FolderError{
+				Message: "Folder not found",
+				Code:    "FOLDER_NOT_FOUND",
+			}
+		}
+		return &FolderError{
+			Message: "Failed to delete folder",
+			Code:    "FOLDER_DELETE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
+
+// nameConflictError converts the repository's naming errors into FolderErrors; nil is returned
+// for other errors
+func nameConflictError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrNameConflict):
+		return &FolderError{
+			Message: "An item with the same name already exists",
+			Code:    "NAME_CONFLICT",
+		}
+	case errors.Is(err, repositories.ErrInvalidConflictPolicy):
+		return &FolderError{
+			Message: "Invalid conflict policy",
+			Code:    "INVALID_CONFLICT_POLICY",
+			Details: "conflictPolicy must be fail or rename for folders",
+		}
+	}
+	return nil
+}
+
+// getOwnedFolder loads a folder that must belong to the user.
+// notFoundCode is the error code reported when the folder is missing or owned by someone else.
+func (s *FolderService) getOwnedFolder(userID, folderID, notFoundCode string) (*models.Folder, error) {
+	// Malformed IDs can never match a folder, so report them as missing instead of a database error
+	if uuid.Validate(folderID) != nil {
+		return nil, &FolderError{
+			Message: "Folder not found",
+			Code:    notFoundCode,
+		}
+	}
+
+	folder, err := repositories.FindFolderByIDForUser(folderID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &FolderError{
+				Message: "Folder not found",
+				Code:    notFoundCode,
+			}
+		}
+		return nil, &FolderError{
+			Message: "Failed to retrieve folder",
+			Code:    "FOLDER_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return folder, nil
+}
@@ -0,0 +1,227 @@
+package folder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// ListChildrenRequest represents the query parameters accepted by directory listings
+type ListChildrenRequest struct {
+	Limit  int      `form:"limit"`
+	Cursor string   `form:"cursor"`
+	Sort   string   `form:"sort"`  // "name" (default), "size" or "created_at"
+	Order  string   `form:"order"` // "asc" (default) or "desc"
+	Tags   []string `form:"tag"`   // only list files carrying all of these tags (repeat the parameter for several)
+}
+
+// DirectoryEntryResponse is the API representation of a folder or file inside a listing
+type DirectoryEntryResponse struct {
+	Type        string               `json:"type"` // "folder" or "file"
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Size        int64                `json:"size"`                  // 0 for folders; see Stats.TotalBytes
+	ContentType *string              `json:"contentType,omitempty"` // files only
+	CreatedAt   time.Time            `json:"createdAt"`
+	Stats       *FolderStatsResponse `json:"stats,omitempty"` // recursive totals, folders only
+}
+
+// ListChildrenResponse represents one page of a directory listing
+type ListChildrenResponse struct {
+	Success    bool                     `json:"success"`
+	Entries    []DirectoryEntryResponse `json:"entries"`
+	NextCursor *string                  `json:"nextCursor"`
+}
+
+// listCursor is the opaque, base64-encoded pagination token handed to clients
+type listCursor struct {
+	Kind  int    `json:"k"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// ListChildren returns a page of the subfolders and files inside a folder owned by the user.
+// A nil folderID lists the user's root (items whose parent is nil).
+func (s *FolderService) ListChildren(userID string, folderID *string, request *ListChildrenRequest) (*ListChildrenResponse, error) {
+	if folderID != nil {
+		if _, err := s.getOwnedFolder(userID, *folderID, "FOLDER_NOT_FOUND"); err != nil {
+			return nil, err
+		}
+	}
+
+	sortBy := request.Sort
+	if sortBy == "" {
+		sortBy = "name"
+	}
+	if sortBy != "name" && sortBy != "size" && sortBy != "created_at" {
+		return nil, &FolderError{
+			Message: "Invalid sort field",
+			Code:    "INVALID_SORT",
+			Details: "sort must be one of name, size or created_at",
+		}
+	}
+
+	var descending bool
+	switch request.Order {
+	case "", "asc":
+	case "desc":
+		descending = true
+	default:
+		return nil, &FolderError{
+			Message: "Invalid sort order",
+			Code:    "INVALID_SORT",
+			Details: "order must be asc or desc",
+		}
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	var after *repositories.DirectoryCursor
+	if request.Cursor != "" {
+		cursor, err := decodeListCursor(request.Cursor, sortBy)
+		if err != nil {
+			return nil, &FolderError{
+				Message: "Invalid cursor",
+				Code:    "INVALID_CURSOR",
+				Details: err.Error(),
+			}
+		}
+		after = cursor
+	}
+
+	tags, err := util.NormalizeTags(request.Tags)
+	if err != nil {
+		return nil, &FolderError{
+			Message: "Invalid tag filter",
+			Code:    "INVALID_FILTER",
+			Details: err.Error(),
+		}
+	}
+
+	// Fetch one extra row to find out whether another page exists
+	entries, err := repositories.ListFolderChildren(userID, folderID, sortBy, descending, after, limit+1, tags)
+	if err != nil {
+		return nil, &FolderError{
+			Message: "Failed to list folder contents",
+			Code:    "FOLDER_LISTING_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	response := &ListChildrenResponse{
+		Success: true,
+		Entries: make([]DirectoryEntryResponse, 0, len(entries)),
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next := encodeListCursor(entries[len(entries)-1], sortBy)
+		response.NextCursor = &next
+	}
+
+	// Aggregate the subfolders on this page in one query
+	var folderIDs []string
+	for _, entry := range entries {
+		if entry.Kind == repositories.EntryKindFolder {
+			folderIDs = append(folderIDs, entry.ID)
+		}
+	}
+	stats, err := repositories.GetFolderStats(userID, folderIDs)
+	if err != nil {
+		return nil, &FolderError{
+			Message: "Failed to compute folder sizes",
+			Code:    "FOLDER_LISTING_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	for _, entry := range entries {
+		item := newDirectoryEntryResponse(entry)
+		if entry.Kind == repositories.EntryKindFolder {
+			item.Stats = NewFolderStatsResponse(stats[entry.ID])
+		}
+		response.Entries = append(response.Entries, item)
+	}
+	return response, nil
+}
+
+// newDirectoryEntryResponse converts a repository listing row into its API representation
+func newDirectoryEntryResponse(entry repositories.DirectoryEntry) DirectoryEntryResponse {
+	entryType := "file"
+	if entry.Kind == repositories.EntryKindFolder {
+		entryType = "folder"
+	}
+	return DirectoryEntryResponse{
+		Type:        entryType,
+		ID:          entry.ID,
+		Name:        entry.Name,
+		Size:        entry.Size,
+		ContentType: entry.ContentType,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
+
+// encodeListCursor builds the pagination token pointing just after the given entry
+func encodeListCursor(entry repositories.DirectoryEntry, sortBy string) string {
+	cursor := listCursor{Kind: entry.Kind, ID: entry.ID}
+	switch sortBy {
+	case "size":
+		cursor.Value = strconv.FormatInt(entry.Size, 10)
+	case "created_at":
+		cursor.Value = entry.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		cursor.Value = entry.Name
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeListCursor parses a pagination token produced by encodeListCursor.
+// The token must have been issued for the same sort field.
+func decodeListCursor(token string, sortBy string) (*repositories.DirectoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	if err := uuid.Validate(cursor.ID); err != nil {
+		return nil, err
+	}
+
+	result := &repositories.DirectoryCursor{Kind: cursor.Kind, ID: cursor.ID}
+	switch sortBy {
+	case "size":
+		size, err := strconv.ParseInt(cursor.Value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		result.SortValue = size
+	case "created_at":
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, err
+		}
+		result.SortValue = createdAt
+	default:
+		result.SortValue = cursor.Value
+	}
+	return result, nil
+}
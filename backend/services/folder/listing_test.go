@@ -0,0 +1,81 @@
+package folder
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+)
+
+func TestListCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 17, 9, 30, 15, 123456789, time.FixedZone("CEST", 2*60*60))
+	entry := repositories.DirectoryEntry{
+		Kind:      repositories.EntryKindFile,
+		ID:        "0b7c6a52-3f1e-4c1a-9d8e-2f5b6c7d8e9f",
+		Name:      "trip, day 1.mp4",
+		Size:      1 << 40,
+		CreatedAt: createdAt,
+	}
+
+	tests := []struct {
+		sortBy string
+		want   interface{}
+	}{
+		{sortBy: "name", want: entry.Name},
+		{sortBy: "", want: entry.Name},
+		{sortBy: "size", want: entry.Size},
+		{sortBy: "created_at", want: createdAt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			cursor, err := decodeListCursor(encodeListCursor(entry, tt.sortBy), tt.sortBy)
+			if err != nil {
+				t.Fatalf("decodeListCursor() error = %v", err)
+			}
+			if cursor.Kind != entry.Kind || cursor.ID != entry.ID {
+				t.Errorf("cursor = {Kind: %d, ID: %s}, want {Kind: %d, ID: %s}", cursor.Kind, cursor.ID, entry.Kind, entry.ID)
+			}
+			if want, ok := tt.want.(time.Time); ok {
+				got, ok := cursor.SortValue.(time.Time)
+				if !ok || !got.Equal(want) {
+					t.Errorf("SortValue = %v, want %v", cursor.SortValue, want)
+				}
+				return
+			}
+			if cursor.SortValue != tt.want {
+				t.Errorf("SortValue = %#v, want %#v", cursor.SortValue, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeListCursorRejectsInvalidTokens(t *testing.T) {
+	entry := repositories.DirectoryEntry{
+		Kind: repositories.EntryKindFolder,
+		ID:   "0b7c6a52-3f1e-4c1a-9d8e-2f5b6c7d8e9f",
+		Name: "photos",
+	}
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		sortBy string
+	}{
+		{name: "not base64", token: "not a cursor!", sortBy: "name"},
+		{name: "not JSON", token: encode("photos"), sortBy: "name"},
+		{name: "invalid ID", token: encode(`{"k":0,"v":"photos","id":"photos"}`), sortBy: "name"},
+		{name: "name cursor sorted by size", token: encodeListCursor(entry, "name"), sortBy: "size"},
+		{name: "name cursor sorted by creation", token: encodeListCursor(entry, "name"), sortBy: "created_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := decodeListCursor(tt.token, tt.sortBy); err == nil {
+				t.Errorf("decodeListCursor() = %+v, want an error", cursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package github
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// appJWTLifetime is how long an app JWT is valid; GitHub accepts at most 10 minutes
+const appJWTLifetime = 9 * time.Minute
+
+// installationTokenRefreshMargin is how long before expiry an installation token is replaced
+const installationTokenRefreshMargin = 5 * time.Minute
+
+// ErrAppNotConfigured is returned when the GitHub App settings are missing
+var ErrAppNotConfigured = errors.New("GitHub App is not configured")
+
+// installationTokens caches the current access token of each installation by token ID,
+// so concurrent requests do not each mint a new one. The map lock only guards the map itself;
+// each entry has its own lock, so minting a token for one installation never blocks another.
+var installationTokens = struct {
+	mu     sync.Mutex
+	tokens map[string]*cachedInstallationToken
+}{tokens: make(map[string]*cachedInstallationToken)}
+
+// cachedInstallationToken is an installation access token together with its expiry.
+// mu is held while the token is checked or replaced.
+type cachedInstallationToken struct {
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// installationTokenEntry returns the cache entry of a storage account, creating it if needed
+func installationTokenEntry(tokenID string) *cachedInstallationToken {
+	installationTokens.mu.Lock()
+	defer installationTokens.mu.Unlock()
+	entry, ok := installationTokens.tokens[tokenID]
+	if !ok {
+		entry = &cachedInstallationToken{}
+		installationTokens.tokens[tokenID] = entry
+	}
+	return entry
+}
+
+// Installation is the subset of a GitHub App installation needed to link it as a storage account
+type Installation struct {
+	ID      int64 `json:"id"`
+	Account struct {
+		Login string `json:"login"`
+	} `json:"account"`
+}
+
+// AppConfigured reports whether the GitHub App settings needed to link installations are present
+func AppConfigured() bool {
+	cfg := config.LoadConfig()
+	return cfg.GitHubAppID != "" && cfg.GitHubAppSlug != "" && cfg.GitHubAppClientID != "" &&
+		cfg.GitHubAppClientSecret != "" && cfg.GitHubAppPrivateKey != ""
+}
+
+// appPrivateKey parses the app's PEM private key, accepting escaped newlines from the environment
+func appPrivateKey() (*rsa.PrivateKey, error) {
+	pem := strings.ReplaceAll(config.LoadConfig().GitHubAppPrivateKey, `\n`, "\n")
+	if pem == "" {
+		return nil, ErrAppNotConfigured
+	}
+	return jwt.ParseRSAPrivateKeyFromPEM([]byte(pem))
+}
+
+// appJWT creates the RS256 JWT that authenticates as the GitHub App itself
+func appJWT() (string, error) {
+	key, err := appPrivateKey()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iat": now.Add(-time.Minute).Unix(), // allow for clock drift between us and GitHub
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": config.LoadConfig().GitHubAppID,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+}
+
+// GetUserInstallations lists the installations of the app that a user can access.
+// The access token must be a user access token obtained through the app's own OAuth flow.
+func (s *GitHubStorageService) GetUserInstallations(userAccessToken string) ([]Installation, error) {
+	client := newClient(userAccessToken)
+	var installations []Installation
+	for page := 1; ; page++ {
+		var result struct {
+			Installations []Installation `json:"installations"`
+		}
+		var errorResponse map[string]interface{}
+		resp, err := client.R().
+			SetQueryParam("per_page", "100").
+			SetQueryParam("page", strconv.Itoa(page)).
+			SetResult(&result).
+			SetError(&errorResponse).
+			Get("/user/installations")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list installations: %v", err)
+		}
+		if !resp.IsSuccess() {
+			return nil, fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+		}
+		installations = append(installations, result.Installations...)
+		if len(result.Installations) < 100 {
+			return installations, nil
+		}
+	}
+}
+
+// CreateInstallationToken mints a new access token for an installation of the app
+// It returns the token and its expiry, usually an hour later
+func (s *GitHubStorageService) CreateInstallationToken(installationID int64) (string, time.Time, error) {
+	appToken, err := appJWT()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	var errorResponse map[string]interface{}
+	resp, err := newClient(appToken).R().
+		SetResult(&result).
+		SetError(&errorResponse).
+		Post(fmt.Sprintf("/app/installations/%d/access_tokens", installationID))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create installation token: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return "", time.Time{}, fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	return result.Token, result.ExpiresAt, nil
+}
+
+// AccessTokenFor returns an access token that can currently be used for a storage account.
+// OAuth tokens are returned as stored. For GitHub App installations a new installation token
+// is minted and stored when the current one is about to expire.
+func AccessTokenFor(token *models.Token) (string, error) {
+	if token.Platform != "GITHUB_APP" {
+		return token.AccessToken, nil
+	}
+	if token.InstallationID == nil {
+		return "", fmt.Errorf("storage account %s has no installation ID", token.ID)
+	}
+
+	// Concurrent callers for the same installation wait here and then reuse the minted token
+	entry := installationTokenEntry(token.ID)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	refreshBefore := time.Now().Add(installationTokenRefreshMargin)
+	if entry.expiresAt.After(refreshBefore) {
+		return entry.accessToken, nil
+	}
+	if token.AccessTokenExpiry != nil && token.AccessTokenExpiry.After(refreshBefore) {
+		entry.accessToken, entry.expiresAt = token.AccessToken, *token.AccessTokenExpiry
+		return token.AccessToken, nil
+	}
+
+	accessToken, expiresAt, err := NewGitHubStorageService().CreateInstallationToken(*token.InstallationID)
+	if err != nil {
+		return "", err
+	}
+	if err := repositories.UpdateInstallationAccessToken(token, accessToken, expiresAt); err != nil {
+		return "", err
+	}
+	entry.accessToken, entry.expiresAt = accessToken, expiresAt
+	return accessToken, nil
+}
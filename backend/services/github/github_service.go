@@ -0,0 +1,370 @@
+package github
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/go-resty/resty/v2"
+)
+
+const apiBaseURL = "https://api.github.com"
+
+// GitHubStorageService talks to the GitHub REST API on behalf of linked storage accounts
+type GitHubStorageService struct{}
+
+// NewGitHubStorageService creates a new instance of GitHubStorageService
+func NewGitHubStorageService() *GitHubStorageService {
+	return &GitHubStorageService{}
+}
+
+// BlobLocation identifies a single file stored in a GitHub repository branch,
+// together with the credential needed to access it
+type BlobLocation struct {
+	Owner       string
+	Repo        string
+	Branch      string
+	Path        string
+	AccessToken string
+}
+
+// LocationForChunk resolves where a pushed chunk lives on GitHub.
+// The chunk must have been loaded with its Branch.Repo.Token association.
+func LocationForChunk(chunk *models.Chunk) (*BlobLocation, error) {
+	if chunk.Branch == nil || chunk.GitPath == nil {
+		return nil, fmt.Errorf("chunk %s has not been pushed to GitHub", chunk.ID)
+	}
+	token := &chunk.Branch.Repo.Token
+	if token.AccountIdentifier == nil {
+		return nil, fmt.Errorf("storage account for chunk %s has no GitHub login", chunk.ID)
+	}
+	accessToken, err := AccessTokenFor(token)
+	if err != nil {
+		return nil, err
+	}
+	return &BlobLocation{
+		Owner:       *token.AccountIdentifier,
+		Repo:        chunk.Branch.Repo.Name,
+		Branch:      chunk.Branch.Name,
+		Path:        *chunk.GitPath,
+		AccessToken: accessToken,
+	}, nil
+}
+
+// newClient creates a resty client authenticated with the given access token
+func newClient(accessToken string) *resty.Client {
+	return resty.New().
+		SetTimeout(30*time.Second).
+		SetBaseURL(apiBaseURL).
+		SetHeader("Accept", "application/vnd.github+json").
+		SetHeader("X-GitHub-Api-Version", "2022-11-28").
+		SetAuthToken(accessToken)
+}
+
+// contentsURL builds the contents API URL for a path inside a repository
+func contentsURL(location *BlobLocation) string {
+	segments := strings.Split(location.Path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("/repos/%s/%s/contents/%s", url.PathEscape(location.Owner), url.PathEscape(location.Repo), strings.Join(segments, "/"))
+}
+
+// GetFileContent downloads the raw bytes of a file stored in a repository branch
+func (s *GitHubStorageService) GetFileContent(location *BlobLocation) ([]byte, error) {
+	var errorResponse map[string]interface{}
+
+	resp, err := newClient(location.AccessToken).R().
+		SetHeader("Accept", "application/vnd.github.raw+json").
+		SetQueryParam("ref", location.Branch).
+		SetError(&errorResponse).
+		Get(contentsURL(location))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file content: %v", err)
+	}
+
+	if !resp.IsSuccess() {
+		return nil, fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+
+	return resp.Body(), nil
+}
+
+// contentMetadata is the subset of the contents API response needed to modify a file
+type contentMetadata struct {
+	SHA string `json:"sha"`
+}
+
+// DeleteFile removes a file from a repository branch by creating a commit on that branch.
+// Deleting a file that no longer exists is not an error.
+func (s *GitHubStorageService) DeleteFile(location *BlobLocation, message string) error {
+	client := newClient(location.AccessToken)
+
+	// The contents API needs the blob SHA of the file being deleted
+	var metadata contentMetadata
+	var errorResponse map[string]interface{}
+	resp, err := client.R().
+		SetQueryParam("ref", location.Branch).
+		SetResult(&metadata).
+		SetError(&errorResponse).
+		Get(contentsURL(location))
+	if err != nil {
+		return fmt.Errorf("failed to look up file: %v", err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+
+	resp, err = client.R().
+		SetBody(map[string]string{
+			"message": message,
+			"sha":     metadata.SHA,
+			"branch":  location.Branch,
+		}).
+		SetError(&errorResponse).
+		Delete(contentsURL(location))
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %v", err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	return nil
+}
+
+// BranchLocation identifies a repository branch together with the credential needed to modify it
+type BranchLocation struct {
+	Owner       string
+	Repo        string
+	Branch      string
+	AccessToken string
+}
+
+// LocationForBranch resolves where a storage branch lives on GitHub.
+// The branch must have been loaded with its Repo.Token association.
+func LocationForBranch(branch *models.Branch) (*BranchLocation, error) {
+	token := &branch.Repo.Token
+	if token.AccountIdentifier == nil {
+		return nil, fmt.Errorf("storage account for branch %s has no GitHub login", branch.ID)
+	}
+	accessToken, err := AccessTokenFor(token)
+	if err != nil {
+		return nil, err
+	}
+	return &BranchLocation{
+		Owner:       *token.AccountIdentifier,
+		Repo:        branch.Repo.Name,
+		Branch:      branch.Name,
+		AccessToken: accessToken,
+	}, nil
+}
+
+// TreeEntry is a single file in a Git tree
+type TreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// gitDataURL builds a Git data API URL inside a repository, e.g. "git/trees"
+func gitDataURL(location *BranchLocation, path string) string {
+	return fmt.Sprintf("/repos/%s/%s/%s", url.PathEscape(location.Owner), url.PathEscape(location.Repo), path)
+}
+
+// branchRef builds the escaped ref name of a branch, keeping the slashes of nested branch names
+func branchRef(branch string) string {
+	segments := strings.Split(branch, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "heads/" + strings.Join(segments, "/")
+}
+
+// GetBranchHead returns the SHA of the commit a branch points at and the SHA of that commit's tree
+func (s *GitHubStorageService) GetBranchHead(location *BranchLocation) (string, string, error) {
+	client := newClient(location.AccessToken)
+
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	var errorResponse map[string]interface{}
+	resp, err := client.R().
+		SetResult(&ref).
+		SetError(&errorResponse).
+		Get(gitDataURL(location, "git/ref/"+branchRef(location.Branch)))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch branch: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return "", "", fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+
+	var commit struct {
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	}
+	resp, err = client.R().
+		SetResult(&commit).
+		SetError(&errorResponse).
+		Get(gitDataURL(location, "git/commits/"+ref.Object.SHA))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch commit: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return "", "", fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	return ref.Object.SHA, commit.Tree.SHA, nil
+}
+
+// GetTreeFiles lists every file in a tree, including those in subdirectories.
+// An error is returned when GitHub truncates the listing, so callers never act on a partial tree.
+func (s *GitHubStorageService) GetTreeFiles(location *BranchLocation, treeSHA string) ([]TreeEntry, error) {
+	var tree struct {
+		Tree      []TreeEntry `json:"tree"`
+		Truncated bool        `json:"truncated"`
+	}
+	var errorResponse map[string]interface{}
+	resp, err := newClient(location.AccessToken).R().
+		SetQueryParam("recursive", "1").
+		SetResult(&tree).
+		SetError(&errorResponse).
+		Get(gitDataURL(location, "git/trees/"+treeSHA))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tree: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return nil, fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	if tree.Truncated {
+		return nil, fmt.Errorf("tree %s is too large to list", treeSHA)
+	}
+
+	files := make([]TreeEntry, 0, len(tree.Tree))
+	for _, entry := range tree.Tree {
+		if entry.Type == "blob" {
+			files = append(files, entry)
+		}
+	}
+	return files, nil
+}
+
+// CreateOrphanCommit creates a tree from existing blobs and a commit of that tree without any
+// parents, returning the commit SHA. The branch is not moved; see ForceUpdateBranch.
+func (s *GitHubStorageService) CreateOrphanCommit(location *BranchLocation, files []TreeEntry, message string) (string, error) {
+	client := newClient(location.AccessToken)
+
+	entries := make([]TreeEntry, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, TreeEntry{Path: file.Path, Mode: file.Mode, Type: "blob", SHA: file.SHA})
+	}
+	var tree struct {
+		SHA string `json:"sha"`
+	}
+	var errorResponse map[string]interface{}
+	resp, err := client.R().
+		SetBody(map[string]interface{}{"tree": entries}).
+		SetResult(&tree).
+		SetError(&errorResponse).
+		Post(gitDataURL(location, "git/trees"))
+	if err != nil {
+		return "", fmt.Errorf("failed to create tree: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return "", fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	resp, err = client.R().
+		SetBody(map[string]interface{}{
+			"message": message,
+			"tree":    tree.SHA,
+			"parents": []string{},
+		}).
+		SetResult(&commit).
+		SetError(&errorResponse).
+		Post(gitDataURL(location, "git/commits"))
+	if err != nil {
+		return "", fmt.Errorf("failed to create commit: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return "", fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	return commit.SHA, nil
+}
+
+// ForceUpdateBranch points a branch at a commit even when the commit does not descend from the
+// branch's current head
+func (s *GitHubStorageService) ForceUpdateBranch(location *BranchLocation, commitSHA string) error {
+	var errorResponse map[string]interface{}
+	resp, err := newClient(location.AccessToken).R().
+		SetBody(map[string]interface{}{
+			"sha":   commitSHA,
+			"force": true,
+		}).
+		SetError(&errorResponse).
+		Patch(gitDataURL(location, "git/refs/"+branchRef(location.Branch)))
+	if err != nil {
+		return fmt.Errorf("failed to update branch: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	return nil
+}
+
+// CreateFile writes a new file to a repository branch by creating a commit on that branch.
+// It fails if a file already exists at the path.
+func (s *GitHubStorageService) CreateFile(location *BlobLocation, content []byte, message string) error {
+	var errorResponse map[string]interface{}
+	resp, err := newClient(location.AccessToken).R().
+		SetBody(map[string]string{
+			"message": message,
+			"content": base64.StdEncoding.EncodeToString(content),
+			"branch":  location.Branch,
+		}).
+		SetError(&errorResponse).
+		Put(contentsURL(location))
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	return nil
+}
+
+// DeleteRepository deletes a repository and everything stored in it.
+// The access token needs the delete_repo scope. Deleting a repository that no longer exists is not an error.
+func (s *GitHubStorageService) DeleteRepository(owner, repo, accessToken string) error {
+	var errorResponse map[string]interface{}
+	resp, err := newClient(accessToken).R().
+		SetError(&errorResponse).
+		Delete(fmt.Sprintf("/repos/%s/%s", url.PathEscape(owner), url.PathEscape(repo)))
+	if err != nil {
+		return fmt.Errorf("failed to delete repository: %v", err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("GitHub API request failed with status %d: %v", resp.StatusCode(), errorResponse)
+	}
+	return nil
+}
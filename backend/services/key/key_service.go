@@ -0,0 +1,116 @@
+package key
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"gorm.io/gorm"
+)
+
+// maxKeyMaterialLength bounds the size of the opaque values clients store
+const maxKeyMaterialLength = 4096
+
+// KeyService manages the wrapped end-to-end encryption keys of users. Keys are wrapped and
+// unwrapped on the client; the server only ever sees the wrapped form.
+type KeyService struct{}
+
+// NewKeyService creates a new instance of KeyService
+func NewKeyService() *KeyService {
+	return &KeyService{}
+}
+
+// SaveKeyRequest represents the request structure for storing a user's wrapped key.
+// Sending it again with a new wrapping (after a passphrase change) replaces the stored one.
+type SaveKeyRequest struct {
+	WrappedKey string `json:"wrappedKey" binding:"required"`
+	Algorithm  string `json:"algorithm" binding:"required"`
+	KDF        string `json:"kdf" binding:"required"`
+	KDFParams  string `json:"kdfParams" binding:"required"`
+}
+
+// KeyResponse is the API representation of a user's wrapped key
+type KeyResponse struct {
+	WrappedKey string    `json:"wrappedKey"`
+	Algorithm  string    `json:"algorithm"`
+	KDF        string    `json:"kdf"`
+	KDFParams  string    `json:"kdfParams"`
+	Version    int       `json:"version"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// KeyError represents a structured error for key operations
+type KeyError struct {
+	Message string
+	Code    string
+	Details string
+}
+
+func (e *KeyError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// NewKeyResponse converts a UserKey model into its API representation
+func NewKeyResponse(key *models.UserKey) *KeyResponse {
+	return &KeyResponse{
+		WrappedKey: key.WrappedKey,
+		Algorithm:  key.Algorithm,
+		KDF:        key.KDF,
+		KDFParams:  key.KDFParams,
+		Version:    key.Version,
+		UpdatedAt:  key.UpdatedAt,
+	}
+}
+
+// GetKey returns the wrapped key of the user
+func (s *KeyService) GetKey(userID string) (*KeyResponse, error) {
+	key, err := repositories.FindUserKey(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &KeyError{
+				Message: "End-to-end encryption has not been set up",
+				Code:    "KEY_NOT_FOUND",
+			}
+		}
+		return nil, &KeyError{
+			Message: "Failed to retrieve key",
+			Code:    "KEY_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return NewKeyResponse(key), nil
+}
+
+// SaveKey stores the wrapped key of the user, replacing the previous wrapping if there is one
+func (s *KeyService) SaveKey(userID string, request *SaveKeyRequest) (*KeyResponse, error) {
+	for _, value := range []string{request.WrappedKey, request.Algorithm, request.KDF, request.KDFParams} {
+		if len(value) > maxKeyMaterialLength {
+			return nil, &KeyError{
+				Message: "Invalid key",
+				Code:    "INVALID_KEY",
+				Details: "values must be at most 4096 bytes",
+			}
+		}
+	}
+	if len(request.Algorithm) > 50 || len(request.KDF) > 50 {
+		return nil, &KeyError{
+			Message: "Invalid key",
+			Code:    "INVALID_KEY",
+			Details: "algorithm and kdf must be at most 50 characters",
+		}
+	}
+
+	key, err := repositories.SaveUserKey(userID, request.WrappedKey, request.Algorithm, request.KDF, request.KDFParams)
+	if err != nil {
+		return nil, &KeyError{
+			Message: "Failed to save key",
+			Code:    "KEY_UPDATE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return NewKeyResponse(key), nil
+}
@@ -0,0 +1,232 @@
+package path
+
+import (
+	"errors"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	fileservice "github.com/AnshJain-Shwalia/DataHub/backend/services/file"
+	folderservice "github.com/AnshJain-Shwalia/DataHub/backend/services/folder"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+	"gorm.io/gorm"
+)
+
+// PathService resolves slash-separated paths such as "/photos/2024/trip.mp4" against a
+// user's folder tree and performs folder and file operations on the resolved item
+type PathService struct {
+	folderService *folderservice.FolderService
+	fileService   *fileservice.FileService
+}
+
+// NewPathService creates a new instance of PathService
+func NewPathService() *PathService {
+	return &PathService{
+		folderService: folderservice.NewFolderService(),
+		fileService:   fileservice.NewFileService(),
+	}
+}
+
+// UploadToPathRequest represents the request structure for uploading a file to a path
+type UploadToPathRequest struct {
+	Size           int64  `json:"size" binding:"min=0"`
+	ConflictPolicy string `json:"conflictPolicy"` // "fail" (default), "rename" or "overwrite"
+}
+
+// StatResponse describes the item a path points to. Exactly one of Folder and File is set,
+// except for the root path "/" which is reported as a folder without details.
+type StatResponse struct {
+	Success bool                          `json:"success"`
+	Path    string                        `json:"path"`
+	Type    string                        `json:"type"` // "folder" or "file"
+	Folder  *folderservice.FolderResponse `json:"folder,omitempty"`
+	File    *fileservice.FileResponse     `json:"file,omitempty"`
+}
+
+// PathError represents a structured error for path resolution
+type PathError struct {
+	Message string
+	Code    string
+	Details string
+}
+
+func (e *PathError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// resolvedPath is the item a path points to; both fields are nil for the root
+type resolvedPath struct {
+	folder *models.Folder
+	file   *models.File
+}
+
+// Stat returns the folder or file the path points to
+func (s *PathService) Stat(userID, path string) (*StatResponse, error) {
+	resolved, err := s.resolve(userID, path)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &StatResponse{Success: true, Path: path, Type: "folder"}
+	switch {
+	case resolved.file != nil:
+		response.Type = "file"
+		response.File = fileservice.NewFileResponse(resolved.file)
+	case resolved.folder != nil:
+		folder, err := s.folderService.GetFolder(userID, resolved.folder.ID)
+		if err != nil {
+			return nil, err
+		}
+		response.Folder = folder
+	}
+	return response, nil
+}
+
+// List returns a page of the contents of the folder the path points to
+func (s *PathService) List(userID, path string, request *folderservice.ListChildrenRequest) (*folderservice.ListChildrenResponse, error) {
+	folderID, err := s.resolveFolder(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	return s.folderService.ListChildren(userID, folderID, request)
+}
+
+// PrepareDownload prepares the download of the file the path points to
+func (s *PathService) PrepareDownload(userID, path string) (*fileservice.Download, error) {
+	resolved, err := s.resolve(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	if resolved.file == nil {
+		return nil, &PathError{
+			Message: "Path does not point to a file",
+			Code:    "NOT_A_FILE",
+		}
+	}
+	return s.fileService.PrepareDownload(userID, resolved.file.ID)
+}
+
+// InitiateUpload starts uploading a new file at the given path. The parent folders must already exist.
+func (s *PathService) InitiateUpload(userID, path string, request *UploadToPathRequest) (*fileservice.InitiateUploadResponse, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, &PathError{
+			Message: "Upload path must include a file name",
+			Code:    "INVALID_PATH",
+		}
+	}
+
+	parentID, err := s.resolveFolderSegments(userID, segments[:len(segments)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	return s.fileService.InitiateUpload(userID, &fileservice.InitiateUploadRequest{
+		Name:           segments[len(segments)-1],
+		FolderID:       parentID,
+		Size:           request.Size,
+		ConflictPolicy: request.ConflictPolicy,
+	})
+}
+
+// resolve finds the folder or file a path points to
+func (s *PathService) resolve(userID, path string) (*resolvedPath, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return &resolvedPath{}, nil
+	}
+
+	parentID, err := s.resolveFolderSegments(userID, segments[:len(segments)-1])
+	if err != nil {
+		return nil, err
+	}
+	name := segments[len(segments)-1]
+
+	folder, err := repositories.FindFolderByName(userID, parentID, name)
+	if err == nil {
+		return &resolvedPath{folder: folder}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, resolutionFailed(err)
+	}
+
+	file, err := repositories.FindFileByName(userID, parentID, name)
+	if err == nil {
+		return &resolvedPath{file: file}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, resolutionFailed(err)
+	}
+	return nil, &PathError{
+		Message: "Path not found",
+		Code:    "PATH_NOT_FOUND",
+	}
+}
+
+// resolveFolder finds the folder a path points to; nil is returned for the root
+func (s *PathService) resolveFolder(userID, path string) (*string, error) {
+	segments, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := s.resolveFolderSegments(userID, segments)
+	if err == nil {
+		return folderID, nil
+	}
+
+	// Distinguish a path that points to a file from one that does not exist at all
+	if pathErr, ok := err.(*PathError); ok && pathErr.Code == "PATH_NOT_FOUND" {
+		if resolved, resolveErr := s.resolve(userID, path); resolveErr == nil && resolved.file != nil {
+			return nil, &PathError{
+				Message: "Path does not point to a folder",
+				Code:    "NOT_A_FOLDER",
+			}
+		}
+	}
+	return nil, err
+}
+
+// resolveFolderSegments resolves folder names from the root downwards to a folder ID
+func (s *PathService) resolveFolderSegments(userID string, segments []string) (*string, error) {
+	folderID, err := repositories.ResolveFolderPath(userID, segments)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &PathError{
+				Message: "Path not found",
+				Code:    "PATH_NOT_FOUND",
+			}
+		}
+		return nil, resolutionFailed(err)
+	}
+	return folderID, nil
+}
+
+// splitPath parses a path into its segments
+func splitPath(path string) ([]string, error) {
+	segments, err := util.SplitPath(path)
+	if err != nil {
+		return nil, &PathError{
+			Message: "Invalid path",
+			Code:    "INVALID_PATH",
+			Details: err.Error(),
+		}
+	}
+	return segments, nil
+}
+
+// resolutionFailed wraps an unexpected database error raised while resolving a path
+func resolutionFailed(err error) error {
+	return &PathError{
+		Message: "Failed to resolve path",
+		Code:    "PATH_RESOLUTION_FAILED",
+		Details: err.Error(),
+	}
+}
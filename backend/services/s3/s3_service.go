@@ -1,8 +1,11 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/AnshJain-Shwalia/DataHub/backend/config"
@@ -10,8 +13,12 @@ import (
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// ErrObjectNotFound is returned when a requested object does not exist in the bucket
+var ErrObjectNotFound = errors.New("object not found")
+
 type S3Service struct {
 	client     *s3.Client
 	bucketName string
@@ -63,7 +70,7 @@ func (s *S3Service) GenerateUploadURL(metadata map[string]string) (*UploadURLRes
 		return nil, fmt.Errorf("missing or invalid chunkId in metadata")
 	}
 	
-	key := fmt.Sprintf("uploads/%s/%s/%s", userID, fileID, chunkID)
+	key := ChunkKey(userID, fileID, chunkID)
 	
 	expirationTime := 15 * time.Minute
 	expiresAt := time.Now().Add(expirationTime)
@@ -86,4 +93,73 @@ func (s *S3Service) GenerateUploadURL(metadata map[string]string) (*UploadURLRes
 		UploadURL: request.URL,
 		ExpiresAt: expiresAt,
 	}, nil
-}
\ No newline at end of file
+}
+
+// ChunkKey returns the object key under which a chunk is buffered in the bucket
+func ChunkKey(userID, fileID, chunkID string) string {
+	return fmt.Sprintf("uploads/%s/%s/%s", userID, fileID, chunkID)
+}
+
+// ThumbnailKey returns the object key under which the thumbnail of a file version is stored in the bucket
+func ThumbnailKey(userID, fileID, versionID string) string {
+	return fmt.Sprintf("thumbnails/%s/%s/%s.jpg", userID, fileID, versionID)
+}
+
+// GetObjectSize returns the size in bytes of an object in the bucket.
+// ErrObjectNotFound is returned when the object has not been uploaded.
+func (s *S3Service) GetObjectSize(key string) (int64, error) {
+	output, err := s.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return 0, ErrObjectNotFound
+		}
+		return 0, fmt.Errorf("failed to head object: %w", err)
+	}
+	return aws.ToInt64(output.ContentLength), nil
+}
+
+// GetObject opens an object in the bucket for reading. The caller must close the returned reader.
+func (s *S3Service) GetObject(key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	return output.Body, nil
+}
+
+// DeleteObject removes an object from the bucket. Deleting a missing object is not an error.
+func (s *S3Service) DeleteObject(key string) error {
+	_, err := s.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// PutObject uploads a small object, such as a generated thumbnail, directly from memory
+func (s *S3Service) PutObject(key string, body []byte, contentType string) error {
+	_, err := s.client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+	return nil
+}
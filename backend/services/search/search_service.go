@@ -0,0 +1,241 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"github.com/AnshJain-Shwalia/DataHub/backend/util"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+	// minQueryLength is the shortest query trigram matching can make use of
+	minQueryLength = 2
+)
+
+// SearchService searches a user's files and folders by name
+type SearchService struct{}
+
+// NewSearchService creates a new instance of SearchService
+func NewSearchService() *SearchService {
+	return &SearchService{}
+}
+
+// SearchRequest represents the query parameters accepted by the search endpoint
+type SearchRequest struct {
+	Query         string   `form:"q"`
+	Type          string   `form:"type"`          // "folder", "file" or empty for both
+	MinSize       *int64   `form:"minSize"`       // bytes; restricts results to files
+	MaxSize       *int64   `form:"maxSize"`       // bytes; restricts results to files
+	CreatedAfter  string   `form:"createdAfter"`  // RFC 3339 timestamp
+	CreatedBefore string   `form:"createdBefore"` // RFC 3339 timestamp
+	FolderID      string   `form:"folderId"`      // only search below this folder
+	Tags          []string `form:"tag"`           // only match files carrying all of these tags
+	Limit         int      `form:"limit"`
+	Offset        int      `form:"offset"`
+}
+
+// SearchResultResponse is the API representation of a matched file or folder
+type SearchResultResponse struct {
+	Type      string    `json:"type"` // "folder" or "file"
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	Score     float64   `json:"score"`
+}
+
+// SearchResponse represents one page of search results
+type SearchResponse struct {
+	Success    bool                   `json:"success"`
+	Results    []SearchResultResponse `json:"results"`
+	NextOffset *int                   `json:"nextOffset"`
+}
+
+// SearchError represents a structured error for search operations
+type SearchError struct {
+	Message string
+	Code    string
+	Details string
+}
+
+func (e *SearchError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// Search finds the user's files and folders whose names match the query, and returns
+// each match with its full path
+func (s *SearchService) Search(userID string, request *SearchRequest) (*SearchResponse, error) {
+	query, err := s.buildQuery(userID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to find out whether another page exists
+	limit := query.Limit
+	query.Limit++
+	results, err := repositories.SearchItems(userID, query)
+	if err != nil {
+		return nil, searchFailed(err)
+	}
+
+	response := &SearchResponse{
+		Success: true,
+		Results: make([]SearchResultResponse, 0, len(results)),
+	}
+	if len(results) > limit {
+		results = results[:limit]
+		next := query.Offset + limit
+		response.NextOffset = &next
+	}
+
+	var parentIDs []string
+	for _, result := range results {
+		if result.ParentID != nil {
+			parentIDs = append(parentIDs, *result.ParentID)
+		}
+	}
+	paths, err := repositories.FindFolderPaths(parentIDs)
+	if err != nil {
+		return nil, searchFailed(err)
+	}
+
+	for _, result := range results {
+		parentPath := ""
+		if result.ParentID != nil {
+			parentPath = paths[*result.ParentID]
+		}
+		resultType := "file"
+		if result.Kind == repositories.EntryKindFolder {
+			resultType = "folder"
+		}
+		response.Results = append(response.Results, SearchResultResponse{
+			Type:      resultType,
+			ID:        result.ID,
+			Name:      result.Name,
+			Path:      parentPath + "/" + result.Name,
+			Size:      result.Size,
+			CreatedAt: result.CreatedAt,
+			Score:     result.Score,
+		})
+	}
+	return response, nil
+}
+
+// buildQuery validates the request and converts it into a repository query
+func (s *SearchService) buildQuery(userID string, request *SearchRequest) (*repositories.SearchQuery, error) {
+	text := strings.TrimSpace(request.Query)
+	if len([]rune(text)) < minQueryLength {
+		return nil, invalidQuery("q must be at least 2 characters long")
+	}
+
+	query := &repositories.SearchQuery{
+		Text:    text,
+		MinSize: request.MinSize,
+		MaxSize: request.MaxSize,
+		Limit:   request.Limit,
+		Offset:  request.Offset,
+	}
+
+	switch request.Type {
+	case "":
+	case "folder":
+		kind := repositories.EntryKindFolder
+		query.Kind = &kind
+	case "file":
+		kind := repositories.EntryKindFile
+		query.Kind = &kind
+	default:
+		return nil, invalidQuery("type must be folder or file")
+	}
+
+	if request.MinSize != nil && request.MaxSize != nil && *request.MinSize > *request.MaxSize {
+		return nil, invalidQuery("minSize must not be greater than maxSize")
+	}
+
+	var err error
+	if query.CreatedAfter, err = parseTime(request.CreatedAfter); err != nil {
+		return nil, invalidQuery("createdAfter must be an RFC 3339 timestamp")
+	}
+	if query.CreatedBefore, err = parseTime(request.CreatedBefore); err != nil {
+		return nil, invalidQuery("createdBefore must be an RFC 3339 timestamp")
+	}
+
+	if query.Tags, err = util.NormalizeTags(request.Tags); err != nil {
+		return nil, invalidQuery(err.Error())
+	}
+
+	if request.FolderID != "" {
+		if err := ensureFolderOwned(userID, request.FolderID); err != nil {
+			return nil, err
+		}
+		query.FolderID = &request.FolderID
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if query.Limit > maxSearchLimit {
+		query.Limit = maxSearchLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+	return query, nil
+}
+
+// ensureFolderOwned checks that the folder a search is scoped to exists and belongs to the user
+func ensureFolderOwned(userID, folderID string) error {
+	if uuid.Validate(folderID) == nil {
+		_, err := repositories.FindFolderByIDForUser(folderID, userID)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return searchFailed(err)
+		}
+	}
+	return &SearchError{
+		Message: "Folder not found",
+		Code:    "FOLDER_NOT_FOUND",
+	}
+}
+
+// parseTime parses an optional RFC 3339 timestamp; an empty value yields nil
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// invalidQuery reports a malformed search request
+func invalidQuery(details string) error {
+	return &SearchError{
+		Message: "Invalid search query",
+		Code:    "INVALID_QUERY",
+		Details: details,
+	}
+}
+
+// searchFailed wraps an unexpected database error raised while searching
+func searchFailed(err error) error {
+	return &SearchError{
+		Message: "Failed to search",
+		Code:    "SEARCH_FAILED",
+		Details: err.Error(),
+	}
+}
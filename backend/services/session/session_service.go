@@ -0,0 +1,120 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/models"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionService lists and revokes the sign-in sessions of users
+type SessionService struct{}
+
+// NewSessionService creates a new instance of SessionService
+func NewSessionService() *SessionService {
+	return &SessionService{}
+}
+
+// SessionResponse is the API representation of a sign-in session
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ipAddress"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"` // whether this is the session making the request
+}
+
+// SessionError represents a structured error for session operations
+type SessionError struct {
+	Message string
+	Code    string
+	Details string
+}
+
+func (e *SessionError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// NewSessionResponse converts a Session model into its API representation
+func NewSessionResponse(session *models.Session, currentSessionID string) *SessionResponse {
+	return &SessionResponse{
+		ID:         session.ID,
+		Device:     session.Device,
+		IPAddress:  session.IPAddress,
+		LastSeenAt: session.LastSeenAt,
+		CreatedAt:  session.CreatedAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == currentSessionID,
+	}
+}
+
+// ListSessions returns the active sessions of the user, most recently seen first
+func (s *SessionService) ListSessions(userID, currentSessionID string) ([]SessionResponse, error) {
+	sessions, err := repositories.ListActiveSessions(userID)
+	if err != nil {
+		return nil, &SessionError{
+			Message: "Failed to retrieve sessions",
+			Code:    "SESSION_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	responses := make([]SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		responses = append(responses, *NewSessionResponse(&sessions[i], currentSessionID))
+	}
+	return responses, nil
+}
+
+// RevokeSession signs the user out of one session. Its refresh tokens stop working and its
+// access tokens are rejected immediately.
+func (s *SessionService) RevokeSession(userID, sessionID string) error {
+	// Malformed IDs can never match a session, so report them as missing instead of a database error
+	if uuid.Validate(sessionID) != nil {
+		return &SessionError{
+			Message: "Session not found",
+			Code:    "SESSION_NOT_FOUND",
+		}
+	}
+
+	if err := repositories.RevokeSession(sessionID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &SessionError{
+				Message: "Session not found",
+				Code:    "SESSION_NOT_FOUND",
+			}
+		}
+		return &SessionError{
+			Message: "Failed to revoke session",
+			Code:    "SESSION_REVOKE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere, optionally keeping one session
+// (usually the one making the request).
+//
+// Returns:
+//   - The number of sessions revoked
+//   - An error if the sessions cannot be revoked
+func (s *SessionService) RevokeAllSessions(userID, exceptSessionID string) (int64, error) {
+	revoked, err := repositories.RevokeUserSessions(userID, exceptSessionID)
+	if err != nil {
+		return 0, &SessionError{
+			Message: "Failed to revoke sessions",
+			Code:    "SESSION_REVOKE_FAILED",
+			Details: err.Error(),
+		}
+	}
+	return revoked, nil
+}
@@ -30,6 +30,16 @@ func (s *TokenService) GetGitHubTokensForUser(userID string) ([]models.Token, er
 	return repositories.GetGitHubTokensForUser(userID)
 }
 
+// GetStorageTokensForUser retrieves all GitHub OAuth and GitHub App installation tokens for a user
+func (s *TokenService) GetStorageTokensForUser(userID string) ([]models.Token, error) {
+	return repositories.GetStorageTokensForUser(userID)
+}
+
+// CreateOrUpdateGitHubAppToken creates or updates the token of a GitHub App installation linked by a user
+func (s *TokenService) CreateOrUpdateGitHubAppToken(userID, accountLogin string, installationID int64, accessToken string, expiry time.Time) (*models.Token, error) {
+	return repositories.CreateOrUpdateGitHubAppToken(userID, accountLogin, installationID, accessToken, expiry)
+}
+
 // GetTokenByID retrieves a token by its ID
 func (s *TokenService) GetTokenByID(tokenID string) (*models.Token, error) {
 	return repositories.GetTokenByID(tokenID)
@@ -0,0 +1,205 @@
+package trash
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/AnshJain-Shwalia/DataHub/backend/config"
+	"github.com/AnshJain-Shwalia/DataHub/backend/repositories"
+	fileservice "github.com/AnshJain-Shwalia/DataHub/backend/services/file"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize bounds how many expired trash items are purged per run
+const purgeBatchSize = 100
+
+// TrashService handles listing, restoring and purging trashed files and folders
+type TrashService struct{}
+
+// NewTrashService creates a new instance of TrashService
+func NewTrashService() *TrashService {
+	return &TrashService{}
+}
+
+// RestoreRequest represents the request structure for restoring a trashed item
+type RestoreRequest struct {
+	ConflictPolicy string `json:"conflictPolicy"` // "fail" (default), "rename" or "overwrite" (files only)
+}
+
+// TrashEntryResponse is the API representation of a top-level trashed item
+type TrashEntryResponse struct {
+	Type         string    `json:"type"` // "folder" or "file"
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	OriginalPath *string   `json:"originalPath"`
+	DeletedAt    time.Time `json:"deletedAt"`
+	PurgeAt      time.Time `json:"purgeAt"`
+}
+
+// ListTrashResponse represents the response structure for listing the trash
+type ListTrashResponse struct {
+	Success bool                 `json:"success"`
+	Entries []TrashEntryResponse `json:"entries"`
+}
+
+// RestoreResponse represents the response structure after restoring a trashed item
+type RestoreResponse struct {
+	Success bool   `json:"success"`
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+}
+
+// TrashError represents a structured error for trash operations
+type TrashError struct {
+	Message string
+	Code    string
+	Details string
+}
+
+func (e *TrashError) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+	return e.Message
+}
+
+// retention returns how long trashed items are kept before they are purged
+func retention() time.Duration {
+	return time.Duration(config.LoadConfig().TrashRetentionDays) * 24 * time.Hour
+}
+
+// entryType converts a repository entry kind into its API name
+func entryType(kind int) string {
+	if kind == repositories.EntryKindFile {
+		return "file"
+	}
+	return "folder"
+}
+
+// ListTrash returns the items the user deleted directly, most recently deleted first
+func (s *TrashService) ListTrash(userID string) (*ListTrashResponse, error) {
+	entries, err := repositories.ListTrash(userID)
+	if err != nil {
+		return nil, &TrashError{
+			Message: "Failed to retrieve trash",
+			Code:    "TRASH_RETRIEVAL_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	keep := retention()
+	response := &ListTrashResponse{
+		Success: true,
+		Entries: make([]TrashEntryResponse, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, TrashEntryResponse{
+			Type:         entryType(entry.Kind),
+			ID:           entry.ID,
+			Name:         entry.Name,
+			Size:         entry.Size,
+			OriginalPath: entry.OriginalPath,
+			DeletedAt:    entry.DeletedAt,
+			PurgeAt:      entry.DeletedAt.Add(keep),
+		})
+	}
+	return response, nil
+}
+
+// Restore puts a trashed item, and everything trashed along with it, back into the user's tree
+func (s *TrashService) Restore(userID, itemID string, request *RestoreRequest) (*RestoreResponse, error) {
+	// Malformed IDs can never match an item, so report them as missing instead of a database error
+	if uuid.Validate(itemID) != nil {
+		return nil, &TrashError{
+			Message: "Trash item not found",
+			Code:    "TRASH_ITEM_NOT_FOUND",
+		}
+	}
+
+	kind, err := repositories.RestoreTrashItem(itemID, userID, request.ConflictPolicy)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, &TrashError{
+				Message: "Trash item not found",
+				Code:    "TRASH_ITEM_NOT_FOUND",
+			}
+		case errors.Is(err, repositories.ErrNameConflict):
+			return nil, &TrashError{
+				Message: "An item with the same name already exists",
+				Code:    "NAME_CONFLICT",
+			}
+		case errors.Is(err, repositories.ErrInvalidConflictPolicy):
+			return nil, &TrashError{
+				Message: "Invalid conflict policy",
+				Code:    "INVALID_CONFLICT_POLICY",
+				Details: "conflictPolicy must be fail, rename or overwrite (files only)",
+			}
+		}
+		return nil, &TrashError{
+			Message: "Failed to restore item",
+			Code:    "TRASH_RESTORE_FAILED",
+			Details: err.Error(),
+		}
+	}
+
+	return &RestoreResponse{
+		Success: true,
+		Type:    entryType(kind),
+		ID:      itemID,
+	}, nil
+}
+
+// PurgeExpired permanently deletes trashed items whose retention period has elapsed.
+// Each item's rows are deleted first, and the chunk blobs and thumbnails nothing else references
+// are then removed from GitHub and the S3 buffer. A blob that cannot be removed is logged and
+// left behind; GitHub blobs are dropped by the next compaction of their branch.
+//
+// Returns:
+//   - The number of trashed items purged
+//   - An error if the expired items cannot be listed
+func (s *TrashService) PurgeExpired() (int, error) {
+	cutoff := time.Now().Add(-retention())
+	roots, err := repositories.FindExpiredTrashRoots(cutoff, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, root := range roots {
+		released, err := repositories.PurgeTrashRoot(root.ID, root.UserID, cutoff)
+		if err != nil {
+			// Items restored since they were listed are simply no longer expired
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("Failed to purge trash item %s of user %s: %v", root.ID, root.UserID, err)
+			}
+			continue
+		}
+		purged++
+		if err := fileservice.DeleteReleasedBlobs(released); err != nil {
+			log.Printf("Failed to delete content of purged trash item %s of user %s: %v", root.ID, root.UserID, err)
+		}
+	}
+	return purged, nil
+}
+
+// StartPurgeWorker runs PurgeExpired in the background at the configured interval
+func (s *TrashService) StartPurgeWorker() {
+	interval := time.Duration(config.LoadConfig().TrashPurgeIntervalMinutes) * time.Minute
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			purged, err := s.PurgeExpired()
+			if err != nil {
+				log.Printf("Trash purge failed: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d expired trash items", purged)
+			}
+			<-ticker.C
+		}
+	}()
+}
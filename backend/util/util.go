@@ -3,6 +3,10 @@ package util
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
 )
 
 func GenerateRandomState() (string, error) {
@@ -13,3 +17,113 @@ func GenerateRandomState() (string, error) {
 	}
 	return base64.URLEncoding.EncodeToString(b), nil
 }
+
+// MaxItemNameLength is the longest file or folder name accepted by the API.
+const MaxItemNameLength = 255
+
+// ValidateItemName checks that a file or folder name can be stored and later
+// addressed by path: it must be non-empty, at most MaxItemNameLength bytes,
+// must not contain a slash or NUL byte, and must not be "." or "..".
+func ValidateItemName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("name must not be empty")
+	case len(name) > MaxItemNameLength:
+		return fmt.Errorf("name must be at most %d bytes", MaxItemNameLength)
+	case strings.ContainsAny(name, "/\x00"):
+		return errors.New("name must not contain '/' or NUL characters")
+	case name == "." || name == "..":
+		return errors.New("name must not be '.' or '..'")
+	}
+	return nil
+}
+
+// SplitPath splits a slash-separated path such as "/photos/2024/trip.mp4" into its
+// segments. Leading and trailing slashes are ignored, so "/" yields no segments.
+// Empty segments ("a//b") and segments that are not valid item names are rejected.
+func SplitPath(path string) ([]string, error) {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil, nil
+	}
+	segments := strings.Split(trimmed, "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, errors.New("path must not contain empty segments")
+		}
+		if err := ValidateItemName(segment); err != nil {
+			return nil, fmt.Errorf("invalid path segment %q: %v", segment, err)
+		}
+	}
+	return segments, nil
+}
+
+// Limits on user-defined file tags and key/value metadata.
+const (
+	MaxTagLength           = 64
+	MaxTagsPerFile         = 50
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 1024
+	MaxMetadataPerFile     = 100
+)
+
+// NormalizeTags trims and lower-cases tags so that "Apollo" and " apollo" are the
+// same tag, and drops duplicates while keeping the first-seen order. Tags must be
+// non-empty, at most MaxTagLength bytes and must not contain commas or control characters.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+			return nil, errors.New("tags must not be empty")
+		case len(tag) > MaxTagLength:
+			return nil, fmt.Errorf("tag %q must be at most %d bytes", tag, MaxTagLength)
+		case strings.ContainsFunc(tag, func(r rune) bool { return r == ',' || unicode.IsControl(r) }):
+			return nil, fmt.Errorf("tag %q must not contain commas or control characters", tag)
+		}
+		if _, exists := seen[tag]; exists {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// ValidateMetadataKey checks that a metadata key is 1 to MaxMetadataKeyLength bytes of
+// letters, digits, '_', '-' or '.'
+func ValidateMetadataKey(key string) error {
+	if key == "" || len(key) > MaxMetadataKeyLength {
+		return fmt.Errorf("metadata key %q must be 1 to %d bytes long", key, MaxMetadataKeyLength)
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			return fmt.Errorf("metadata key %q may only contain letters, digits, '_', '-' and '.'", key)
+		}
+	}
+	return nil
+}
+
+// ValidateChunkHashes checks that chunk hashes, when given, cover every chunk and are lowercase
+// hex SHA-256 digests
+func ValidateChunkHashes(hashes []string, chunkCount int) error {
+	if hashes == nil {
+		return nil
+	}
+	if len(hashes) != chunkCount {
+		return fmt.Errorf("expected %d chunk hashes, got %d", chunkCount, len(hashes))
+	}
+	for i, hash := range hashes {
+		if !IsSHA256Hex(hash) {
+			return fmt.Errorf("chunk hash %d is not a lowercase hex SHA-256 digest", i)
+		}
+	}
+	return nil
+}
+
+// IsSHA256Hex reports whether a string is a lowercase hex SHA-256 digest
+func IsSHA256Hex(hash string) bool {
+	return len(hash) == 64 && strings.Trim(hash, "0123456789abcdef") == ""
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateItemName(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    string
+		wantErr bool
+	}{
+		{name: "plain name", item: "report.pdf"},
+		{name: "spaces and unicode", item: "Résumé 2024 (final).docx"},
+		{name: "leading dot", item: ".gitignore"},
+		{name: "three dots", item: "..."},
+		{name: "longest name", item: strings.Repeat("a", MaxItemNameLength)},
+		{name: "empty", item: "", wantErr: true},
+		{name: "only whitespace", item: " \t ", wantErr: true},
+		{name: "too long", item: strings.Repeat("a", MaxItemNameLength+1), wantErr: true},
+		{name: "too long in bytes", item: strings.Repeat("é", MaxItemNameLength/2+1), wantErr: true},
+		{name: "slash", item: "photos/trip.jpg", wantErr: true},
+		{name: "NUL byte", item: "trip\x00.jpg", wantErr: true},
+		{name: "dot", item: ".", wantErr: true},
+		{name: "dot dot", item: "..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateItemName(tt.item)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateItemName(%q) error = %v, wantErr %v", tt.item, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "no tags", tags: nil, want: []string{}},
+		{name: "trims and lower-cases", tags: []string{" Apollo ", "MOON"}, want: []string{"apollo", "moon"}},
+		{name: "drops duplicates in first-seen order", tags: []string{"moon", "Apollo", "apollo", " MOON"}, want: []string{"moon", "apollo"}},
+		{name: "keeps inner spaces", tags: []string{"Road Trip"}, want: []string{"road trip"}},
+		{name: "longest tag", tags: []string{strings.Repeat("a", MaxTagLength)}, want: []string{strings.Repeat("a", MaxTagLength)}},
+		{name: "empty", tags: []string{"apollo", ""}, wantErr: true},
+		{name: "only whitespace", tags: []string{"  "}, wantErr: true},
+		{name: "too long", tags: []string{strings.Repeat("a", MaxTagLength+1)}, wantErr: true},
+		{name: "surrounding spaces do not count", tags: []string{" " + strings.Repeat("a", MaxTagLength) + " "}, want: []string{strings.Repeat("a", MaxTagLength)}},
+		{name: "comma", tags: []string{"apollo,moon"}, wantErr: true},
+		{name: "control character", tags: []string{"apollo\tmoon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTags(tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeTags(%q) error = %v, wantErr %v", tt.tags, err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("NormalizeTags(%q) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+}